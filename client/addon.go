@@ -0,0 +1,137 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// AddonProduct is an addon that can be ordered for a server, such as an
+// additional IPv4 address or subnet, as returned by
+// /order/server_addon/{number}/product.
+type AddonProduct struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Type is e.g. "ip_ipv4", "subnet_ipv4" or "failover_subnet_ipv4".
+	Type  string     `json:"type"`
+	Price AddonPrice `json:"price"`
+}
+
+// AddonPrice is the price of an addon in the location of its server.
+type AddonPrice struct {
+	// Type is "monthly" or "hourly".
+	Type       string `json:"type"`
+	Location   string `json:"location"`
+	Price      Price  `json:"price"`
+	PriceSetup Price  `json:"price_setup"`
+}
+
+// AddonOrder is the form of POST /order/server_addon/transaction.
+type AddonOrder struct {
+	ServerNumber int    `form:"server_number"`
+	ProductID    string `form:"product_id"`
+	// Reason justifies additional IPv4 addresses to RIPE and is required
+	// for them.
+	Reason  string `form:"reason,omitempty"`
+	Gateway string `form:"gateway,omitempty"`
+	// Test validates the order without placing it.
+	Test bool `form:"test"`
+}
+
+// AddonResource is something allocated by an addon order.
+type AddonResource struct {
+	// Type is "ip" or "subnet"; ID is the address or network address.
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+// AddonTransaction is an order of a server addon. Its Resources are filled in
+// once the addon has been allocated.
+type AddonTransaction struct {
+	ID           string `json:"id"`
+	Date         string `json:"date"`
+	Status       string `json:"status"`
+	ServerNumber int    `json:"server_number"`
+	Product      struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"product"`
+	Resources []AddonResource `json:"resources"`
+}
+
+func (c orderingService) AddonProducts(ctx context.Context, serverNumber int) ([]AddonProduct, error) {
+	path := fmt.Sprintf("/order/server_addon/%d/product", serverNumber)
+	resp, err := c.DoRequest(ctx, "GET", path, nil, "")
+	if err != nil {
+		return nil, fmt.Errorf("error fetching addons of server %d: %w", serverNumber, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error fetching addons of server %d: %w", serverNumber, newRobotError(resp))
+	}
+	var result []struct {
+		Product AddonProduct `json:"product"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("error parsing addons of server %d: %w", serverNumber, err)
+	}
+	products := make([]AddonProduct, 0, len(result))
+	for _, p := range result {
+		products = append(products, p.Product)
+	}
+	return products, nil
+}
+
+func (c orderingService) OrderAddon(ctx context.Context, order AddonOrder) (*AddonTransaction, error) {
+	resp, err := c.doForm(ctx, "POST", "/order/server_addon/transaction", order)
+	if err != nil {
+		return nil, fmt.Errorf("error ordering %s for server %d: %w", order.ProductID, order.ServerNumber, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return nil, fmt.Errorf("error ordering %s for server %d: %w", order.ProductID, order.ServerNumber, newRobotError(resp))
+	}
+	return decodeAddonTransaction(resp)
+}
+
+func (c orderingService) AddonTransaction(ctx context.Context, id string) (*AddonTransaction, error) {
+	path := "/order/server_addon/transaction/" + url.PathEscape(id)
+	resp, err := c.DoRequest(ctx, "GET", path, nil, "")
+	if err != nil {
+		return nil, fmt.Errorf("error fetching transaction %s: %w", id, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error fetching transaction %s: %w", id, newRobotError(resp))
+	}
+	return decodeAddonTransaction(resp)
+}
+
+func (c orderingService) WaitAddonTransaction(ctx context.Context, id string, timeout time.Duration) (*AddonTransaction, error) {
+	var tx *AddonTransaction
+	err := c.waitTransaction(ctx, id, timeout, func(ctx context.Context) (bool, string, error) {
+		var err error
+		tx, err = c.AddonTransaction(ctx, id)
+		if err != nil {
+			return false, "", err
+		}
+		return tx.Status == "ready" && len(tx.Resources) > 0, tx.Status, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+func decodeAddonTransaction(resp *http.Response) (*AddonTransaction, error) {
+	var result struct {
+		Transaction AddonTransaction `json:"transaction"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("error parsing transaction response: %w", err)
+	}
+	return &result.Transaction, nil
+}
@@ -0,0 +1,166 @@
+// Package client is a Go SDK for the Hetzner Robot webservice. It is used by
+// the Terraform provider but has no dependency on it:
+//
+//	c, err := client.NewHetznerRobotClient(&shared.ProviderConfig{
+//		Username: user,
+//		Password: pass,
+//		BaseURL:  "https://robot-ws.your-server.de",
+//	})
+//	servers, err := c.Servers().List(ctx)
+//
+// Code that only needs some of the API should accept a RobotAPI, so that it
+// can be tested against a stub.
+package client
+
+import (
+	"context"
+	"time"
+)
+
+// RobotAPI is the Hetzner Robot webservice, grouped by area. The provider's
+// resources and data sources only use this interface, so that they can be
+// exercised against a stub; *HetznerRobotClient is the real implementation.
+type RobotAPI interface {
+	Servers() ServerService
+	VSwitches() VSwitchService
+	Firewalls() FirewallService
+	Boot() BootService
+	Reset() ResetService
+	Installer() InstallerService
+	Ordering() OrderingService
+
+	// LogContext returns ctx prepared for logging to the given subsystem
+	// with all credentials masked.
+	LogContext(ctx context.Context, subsystem string) context.Context
+}
+
+// ServerService covers /server. Reads are served from a listing cached for
+// the lifetime of the client unless noted otherwise.
+type ServerService interface {
+	List(ctx context.Context) ([]Server, error)
+	Get(ctx context.Context, number int) (*Server, error)
+	GetByIP(ctx context.Context, ip string) (*Server, error)
+	// GetMany returns the servers with the given numbers, sorted by number.
+	// It fails if any of them does not exist.
+	GetMany(ctx context.Context, numbers []int) ([]Server, error)
+	// ListByName returns all servers with the given name; Robot does not
+	// enforce unique names.
+	ListByName(ctx context.Context, name string) ([]Server, error)
+	// Fetch reads a server directly from Robot, bypassing the cache.
+	Fetch(ctx context.Context, number int) (*Server, error)
+	Rename(ctx context.Context, number int, name string) (*Server, error)
+
+	// Cancellation returns the cancellation state of a server, including
+	// the earliest possible date and the accepted reasons.
+	Cancellation(ctx context.Context, number int) (*Cancellation, error)
+	Cancel(ctx context.Context, number int, req CancelRequest) (*Cancellation, error)
+	// RevokeCancellation withdraws a cancellation that has not taken
+	// effect yet.
+	RevokeCancellation(ctx context.Context, number int) error
+
+	// Reversal reports whether the order of a server can still be
+	// withdrawn.
+	Reversal(ctx context.Context, number int) (*Reversal, error)
+	Reverse(ctx context.Context, number int, reason string) (*Reversal, error)
+}
+
+// VSwitchService covers /vswitch.
+type VSwitchService interface {
+	List(ctx context.Context) ([]VSwitch, error)
+	Get(ctx context.Context, id int) (*VSwitch, error)
+	GetMany(ctx context.Context, ids []int) ([]VSwitch, error)
+	Create(ctx context.Context, name string, vlan int) (*VSwitch, error)
+	// Update renames a vSwitch and, if vlan differs from oldVLAN, moves it
+	// to vlan.
+	Update(ctx context.Context, id int, name string, vlan, oldVLAN int) error
+	// Delete cancels a vSwitch at cancellationDate ("now" or YYYY-MM-DD).
+	Delete(ctx context.Context, id int, cancellationDate string) error
+	AddServers(ctx context.Context, id int, servers []VSwitchServer) error
+	RemoveServers(ctx context.Context, id int, servers []VSwitchServer) error
+	// WaitReady polls until no server assignment of the vSwitch is in
+	// process, for at most timeout (zero: until ctx is done). Assignments
+	// that ended in "failed" are reported as ErrOperationFailed.
+	WaitReady(ctx context.Context, id int, timeout time.Duration) error
+}
+
+// FirewallService covers /firewall. Firewalls are addressed by the main IP
+// of their server.
+type FirewallService interface {
+	Get(ctx context.Context, serverIP string) (*HetznerRobotFirewall, error)
+	// Set replaces the firewall configuration and waits, for at most
+	// timeout (zero: until ctx is done), until Robot has applied it.
+	Set(ctx context.Context, firewall HetznerRobotFirewall, timeout time.Duration) error
+}
+
+// BootService covers /boot.
+type BootService interface {
+	EnableRescue(ctx context.Context, number int, os string, sshKeys []string) (*Rescue, error)
+	DisableRescue(ctx context.Context, number int) error
+}
+
+// ResetService covers /reset.
+type ResetService interface {
+	// Reset sends a single reset of the given type (sw, hw, power, ...).
+	Reset(ctx context.Context, number int, resetType string) error
+	// Reboot resets the server and, for the power types, presses the power
+	// button again after Config.PowerCycleWait so that it comes back up.
+	// It fails before resetting if the deadline of ctx leaves less time
+	// than that.
+	Reboot(ctx context.Context, number int, resetType string) error
+}
+
+// InstallerService runs the OS installer on a server booted into the rescue
+// system, over SSH.
+type InstallerService interface {
+	WaitForSSH(ctx context.Context, serverIP string, timeout, interval time.Duration) error
+	InstallTalos(ctx context.Context, serverIP, password string) error
+	WipeDisks(ctx context.Context, serverIP, password string) error
+}
+
+// OrderingService covers /order. Orders cost money unless placed with the
+// test flag.
+type OrderingService interface {
+	ServerProducts(ctx context.Context) ([]ServerProduct, error)
+	OrderServer(ctx context.Context, order ServerOrder) (*ServerTransaction, error)
+	ServerTransaction(ctx context.Context, id string) (*ServerTransaction, error)
+	// WaitServerTransaction polls, for at most timeout (zero: until ctx is
+	// done), until the server of a transaction has been provisioned.
+	// Cancelled transactions are reported as ErrOperationFailed.
+	WaitServerTransaction(ctx context.Context, id string, timeout time.Duration) (*ServerTransaction, error)
+
+	// MarketProducts lists the server market (auction). Market orders
+	// behave like standard ones but have their own transactions.
+	MarketProducts(ctx context.Context) ([]MarketProduct, error)
+	OrderMarketServer(ctx context.Context, order MarketOrder) (*ServerTransaction, error)
+	MarketTransaction(ctx context.Context, id string) (*ServerTransaction, error)
+	WaitMarketTransaction(ctx context.Context, id string, timeout time.Duration) (*ServerTransaction, error)
+
+	// AddonProducts lists the addons, such as additional IPs and subnets,
+	// that can be ordered for a server.
+	AddonProducts(ctx context.Context, serverNumber int) ([]AddonProduct, error)
+	OrderAddon(ctx context.Context, order AddonOrder) (*AddonTransaction, error)
+	AddonTransaction(ctx context.Context, id string) (*AddonTransaction, error)
+	// WaitAddonTransaction polls, for at most timeout (zero: until ctx is
+	// done), until the addon has been allocated.
+	WaitAddonTransaction(ctx context.Context, id string, timeout time.Duration) (*AddonTransaction, error)
+}
+
+var _ RobotAPI = (*HetznerRobotClient)(nil)
+
+type (
+	serverService    struct{ *HetznerRobotClient }
+	vswitchService   struct{ *HetznerRobotClient }
+	firewallService  struct{ *HetznerRobotClient }
+	bootService      struct{ *HetznerRobotClient }
+	resetService     struct{ *HetznerRobotClient }
+	installerService struct{ *HetznerRobotClient }
+	orderingService  struct{ *HetznerRobotClient }
+)
+
+func (c *HetznerRobotClient) Servers() ServerService      { return serverService{c} }
+func (c *HetznerRobotClient) VSwitches() VSwitchService   { return vswitchService{c} }
+func (c *HetznerRobotClient) Firewalls() FirewallService  { return firewallService{c} }
+func (c *HetznerRobotClient) Boot() BootService           { return bootService{c} }
+func (c *HetznerRobotClient) Reset() ResetService         { return resetService{c} }
+func (c *HetznerRobotClient) Installer() InstallerService { return installerService{c} }
+func (c *HetznerRobotClient) Ordering() OrderingService   { return orderingService{c} }
@@ -0,0 +1,148 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/user"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// AuditEntry is one line of the audit journal. It describes a single
+// mutating request sent to the Robot webservice; retries are separate entries.
+type AuditEntry struct {
+	Time       time.Time           `json:"time"`
+	RobotUser  string              `json:"robot_user"`
+	OSUser     string              `json:"os_user,omitempty"`
+	Resource   string              `json:"resource,omitempty"`
+	Method     string              `json:"method"`
+	Path       string              `json:"path"`
+	Form       map[string][]string `json:"form,omitempty"`
+	Attempt    int                 `json:"attempt"`
+	Status     int                 `json:"status,omitempty"`
+	ErrorCode  string              `json:"error_code,omitempty"`
+	Error      string              `json:"error,omitempty"`
+	DurationMs int64               `json:"duration_ms"`
+}
+
+// auditLog appends AuditEntry lines to a file. A nil *auditLog records
+// nothing.
+type auditLog struct {
+	path   string
+	osUser string
+	mu     sync.Mutex
+}
+
+func newAuditLog(path string) (*auditLog, error) {
+	if path == "" {
+		return nil, nil
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("error opening audit log: %w", err)
+	}
+	f.Close()
+
+	osUser := os.Getenv("USER")
+	if u, err := user.Current(); err == nil {
+		osUser = u.Username
+	}
+	return &auditLog{path: path, osUser: osUser}, nil
+}
+
+type auditResourceKey struct{}
+
+// WithAuditResource tags ctx with the Terraform resource on whose behalf
+// requests are made, so that the audit journal can attribute them. id may be
+// empty while the resource is being created.
+func WithAuditResource(ctx context.Context, resourceType, id string) context.Context {
+	resource := resourceType
+	if id != "" {
+		resource += "/" + id
+	}
+	return context.WithValue(ctx, auditResourceKey{}, resource)
+}
+
+// record appends an entry for a request that was sent. resp or err describe
+// its outcome; a failure to write the journal is logged but does not fail the
+// request, which has already been made.
+func (a *auditLog) record(ctx context.Context, c *HetznerRobotClient, method, path string, payload []byte, attempt int, start time.Time, resp *http.Response, err error) {
+	if a == nil || method == http.MethodGet {
+		return
+	}
+	entry := AuditEntry{
+		Time:       start.UTC(),
+		RobotUser:  c.Config.Username,
+		OSUser:     a.osUser,
+		Method:     method,
+		Path:       path,
+		Form:       redactForm(payload),
+		Attempt:    attempt + 1,
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+	if resource, ok := ctx.Value(auditResourceKey{}).(string); ok {
+		entry.Resource = resource
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	if resp != nil {
+		entry.Status = resp.StatusCode
+		if resp.StatusCode >= http.StatusBadRequest {
+			entry.ErrorCode = parseRobotError(resp.StatusCode, []byte(peekBody(resp))).Code
+		}
+	}
+
+	line, err := json.Marshal(entry)
+	if err == nil {
+		err = a.append(append(line, '\n'))
+	}
+	if err != nil {
+		tflog.SubsystemWarn(ctx, SubsystemClient, "Failed to write audit log entry", map[string]interface{}{
+			"audit_log": a.path,
+			"error":     err.Error(),
+		})
+	}
+}
+
+func (a *auditLog) append(line []byte) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	f, err := os.OpenFile(a.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(line); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// redactForm decodes a form body and blanks every value whose key looks like
+// a secret.
+func redactForm(payload []byte) map[string][]string {
+	if len(payload) == 0 {
+		return nil
+	}
+	form, err := url.ParseQuery(string(payload))
+	if err != nil {
+		return map[string][]string{"<unparsable>": {"***"}}
+	}
+	for key, values := range form {
+		lower := strings.ToLower(key)
+		if strings.Contains(lower, "password") || strings.Contains(lower, "secret") || strings.Contains(lower, "token") {
+			for i := range values {
+				values[i] = "***"
+			}
+		}
+	}
+	return form
+}
@@ -0,0 +1,89 @@
+package client_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/client"
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/robotfake"
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/shared"
+)
+
+func readAuditLog(t *testing.T, path string) []client.AuditEntry {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var entries []client.AuditEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e client.AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("invalid audit line %q: %v", scanner.Text(), err)
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+func TestAuditLogRecordsMutatingRequests(t *testing.T) {
+	fake := robotfake.New(robotfake.Options{})
+	defer fake.Close()
+	fake.AddServer(robotfake.Server{Number: 321, Reset: true})
+	fake.AddServer(robotfake.Server{Number: 322})
+
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	c := fake.NewClient(t, func(c *shared.ProviderConfig) {
+		c.AuditLogFile = path
+	})
+	ctx := client.WithAuditResource(context.Background(), "hetznerrobot_os_install", "installer")
+
+	if _, err := c.Servers().Fetch(ctx, 321); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Reset().Reset(ctx, 321, "hw"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Reset().Reset(ctx, 322, "hw"); err == nil {
+		t.Fatal("reset of a server without reset option succeeded")
+	}
+	resp, err := c.DoRequest(ctx, "POST", "/boot/321/linux", strings.NewReader("dist=Debian&password=hunter2"), "application/x-www-form-urlencoded")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	entries := readAuditLog(t, path)
+	if len(entries) != 3 {
+		t.Fatalf("got %d audit entries, want 3 (GETs are not audited): %+v", len(entries), entries)
+	}
+	ok, failed, redacted := entries[0], entries[1], entries[2]
+	if ok.Method != "POST" || ok.Path != "/reset/321" || ok.Status != 200 || ok.Form["type"][0] != "hw" {
+		t.Errorf("reset entry = %+v", ok)
+	}
+	if ok.Resource != "hetznerrobot_os_install/installer" || ok.RobotUser != fake.Username() || ok.Time.IsZero() {
+		t.Errorf("reset entry attribution = %+v", ok)
+	}
+	if failed.Status != 404 || failed.ErrorCode != "RESET_NOT_AVAILABLE" {
+		t.Errorf("failed reset entry = %+v", failed)
+	}
+	if redacted.Form["password"][0] != "***" || redacted.Form["dist"][0] != "Debian" {
+		t.Errorf("form not redacted: %+v", redacted.Form)
+	}
+}
+
+func TestAuditLogMustBeWritable(t *testing.T) {
+	_, err := client.NewHetznerRobotClient(&shared.ProviderConfig{
+		AuditLogFile: filepath.Join(t.TempDir(), "missing-dir", "audit.jsonl"),
+	})
+	if err == nil {
+		t.Fatal("accepted an audit log in a missing directory")
+	}
+}
@@ -0,0 +1,158 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// Cancellation is the cancellation state of a server, as returned by
+// /server/{number}/cancellation.
+type Cancellation struct {
+	ServerIP                 string `json:"server_ip"`
+	ServerNumber             int    `json:"server_number"`
+	ServerName               string `json:"server_name"`
+	EarliestCancellationDate string `json:"earliest_cancellation_date"`
+	Cancelled                bool   `json:"cancelled"`
+	ReservationPossible      bool   `json:"reservation_possible"`
+	Reserved                 bool   `json:"reserved"`
+	// CancellationDate is empty unless the server is cancelled.
+	CancellationDate string `json:"cancellation_date"`
+
+	// Robot reuses cancellation_reason: while the server is not cancelled
+	// it lists the reasons that can be given, afterwards it holds the one
+	// that was given.
+	Reasons []string `json:"-"`
+	Reason  string   `json:"-"`
+}
+
+func (c *Cancellation) UnmarshalJSON(data []byte) error {
+	type plain Cancellation
+	var raw struct {
+		plain
+		Reason json.RawMessage `json:"cancellation_reason"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*c = Cancellation(raw.plain)
+	if len(raw.Reason) > 0 && raw.Reason[0] == '[' {
+		return json.Unmarshal(raw.Reason, &c.Reasons)
+	}
+	if len(raw.Reason) > 0 && raw.Reason[0] == '"' {
+		return json.Unmarshal(raw.Reason, &c.Reason)
+	}
+	return nil
+}
+
+// CancelRequest is the form of POST /server/{number}/cancellation.
+type CancelRequest struct {
+	// Date is "now" or a date (YYYY-MM-DD) not before the earliest
+	// cancellation date.
+	Date string `form:"cancellation_date"`
+	// Reason must be one of Cancellation.Reasons, if set.
+	Reason          string `form:"cancellation_reason,omitempty"`
+	ReserveLocation bool   `form:"reserve_location,omitempty"`
+}
+
+func (c serverService) Cancellation(ctx context.Context, number int) (*Cancellation, error) {
+	path := fmt.Sprintf("/server/%d/cancellation", number)
+	resp, err := c.DoRequest(ctx, "GET", path, nil, "")
+	if err != nil {
+		return nil, fmt.Errorf("error fetching cancellation of server %d: %w", number, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error fetching cancellation of server %d: %w", number, newRobotError(resp))
+	}
+	return decodeCancellation(resp)
+}
+
+func (c serverService) Cancel(ctx context.Context, number int, req CancelRequest) (*Cancellation, error) {
+	path := fmt.Sprintf("/server/%d/cancellation", number)
+	resp, err := c.doForm(ctx, "POST", path, req)
+	if err != nil {
+		return nil, fmt.Errorf("error cancelling server %d: %w", number, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error cancelling server %d: %w", number, newRobotError(resp))
+	}
+	return decodeCancellation(resp)
+}
+
+func (c serverService) RevokeCancellation(ctx context.Context, number int) error {
+	path := fmt.Sprintf("/server/%d/cancellation", number)
+	resp, err := c.DoRequest(ctx, "DELETE", path, nil, "")
+	if err != nil {
+		return fmt.Errorf("error revoking cancellation of server %d: %w", number, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("error revoking cancellation of server %d: %w", number, newRobotError(resp))
+	}
+	return nil
+}
+
+func decodeCancellation(resp *http.Response) (*Cancellation, error) {
+	var result struct {
+		Cancellation Cancellation `json:"cancellation"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("error parsing cancellation response: %w", err)
+	}
+	return &result.Cancellation, nil
+}
+
+// Reversal is the reversal state of a server, as returned by
+// /server/{number}/reversal. An order can be reversed during the withdrawal
+// period after it was placed.
+type Reversal struct {
+	ServerIP         string `json:"server_ip"`
+	ServerNumber     int    `json:"server_number"`
+	ServerName       string `json:"server_name"`
+	ReversalPossible bool   `json:"reversal_possible"`
+	Reversed         bool   `json:"reversed"`
+	ReversalReason   string `json:"reversal_reason"`
+}
+
+type reversalRequest struct {
+	Reason string `form:"reversal_reason,omitempty"`
+}
+
+func (c serverService) Reversal(ctx context.Context, number int) (*Reversal, error) {
+	path := fmt.Sprintf("/server/%d/reversal", number)
+	resp, err := c.DoRequest(ctx, "GET", path, nil, "")
+	if err != nil {
+		return nil, fmt.Errorf("error fetching reversal of server %d: %w", number, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error fetching reversal of server %d: %w", number, newRobotError(resp))
+	}
+	return decodeReversal(resp)
+}
+
+func (c serverService) Reverse(ctx context.Context, number int, reason string) (*Reversal, error) {
+	path := fmt.Sprintf("/server/%d/reversal", number)
+	resp, err := c.doForm(ctx, "POST", path, reversalRequest{Reason: reason})
+	if err != nil {
+		return nil, fmt.Errorf("error reversing server %d: %w", number, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error reversing server %d: %w", number, newRobotError(resp))
+	}
+	return decodeReversal(resp)
+}
+
+func decodeReversal(resp *http.Response) (*Reversal, error) {
+	var result struct {
+		Reversal Reversal `json:"reversal"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("error parsing reversal response: %w", err)
+	}
+	return &result.Reversal, nil
+}
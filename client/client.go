@@ -1,31 +1,206 @@
 package client
 
 import (
+	"bytes"
+	"context"
 	"fmt"
-	"hcloud-robot-provider/shared"
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/shared"
 	"io"
 	"net/http"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+const (
+	defaultRetryWaitMin   = 1 * time.Second
+	defaultRetryWaitMax   = 30 * time.Second
+	defaultRescueSSHPort  = 22
+	defaultPowerCycleWait = 30 * time.Second
 )
 
-func NewHetznerRobotClient(config *shared.ProviderConfig) *HetznerRobotClient {
+func NewHetznerRobotClient(config *shared.ProviderConfig) (*HetznerRobotClient, error) {
+	if config.RetryWaitMin <= 0 {
+		config.RetryWaitMin = defaultRetryWaitMin
+	}
+	if config.RetryWaitMax < config.RetryWaitMin {
+		config.RetryWaitMax = defaultRetryWaitMax
+		if config.RetryWaitMax < config.RetryWaitMin {
+			config.RetryWaitMax = config.RetryWaitMin
+		}
+	}
+	if config.RescueSSHPort <= 0 {
+		config.RescueSSHPort = defaultRescueSSHPort
+	}
+	if config.PowerCycleWait <= 0 {
+		config.PowerCycleWait = defaultPowerCycleWait
+	}
+	if config.PollMinInterval <= 0 {
+		config.PollMinInterval = defaultPollMinInterval
+	}
+	if config.PollMaxInterval < config.PollMinInterval {
+		config.PollMaxInterval = defaultPollMaxInterval
+		if config.PollMaxInterval < config.PollMinInterval {
+			config.PollMaxInterval = config.PollMinInterval
+		}
+	}
+	if config.UserAgent == "" {
+		config.UserAgent = defaultUserAgent
+	}
+	httpClient, err := newHTTPClient(config)
+	if err != nil {
+		return nil, err
+	}
+	audit, err := newAuditLog(config.AuditLogFile)
+	if err != nil {
+		return nil, err
+	}
 	return &HetznerRobotClient{
-		Config: config,
-		Client: &http.Client{},
+		Config:  config,
+		Client:  httpClient,
+		limiter: newRateLimiter(config.RateLimits),
+		audit:   audit,
+	}, nil
+}
+
+// DoRequest sends a request to the Robot webservice, transparently retrying
+// rate-limited, maintenance and transient failures according to the retry
+// settings of the provider configuration.
+func (c *HetznerRobotClient) DoRequest(ctx context.Context, method, path string, body io.Reader, contentType string) (*http.Response, error) {
+	if err := c.checkWritable(method, path); err != nil {
+		return nil, err
+	}
+	var payload []byte
+	if body != nil {
+		var err error
+		payload, err = io.ReadAll(body)
+		if err != nil {
+			return nil, fmt.Errorf("error reading request body: %w", err)
+		}
+	}
+
+	ctx = c.LogContext(ctx, SubsystemClient)
+	for attempt := 0; ; attempt++ {
+		if err := c.limiter.Wait(ctx, path); err != nil {
+			return nil, err
+		}
+		req, err := c.newRequest(ctx, method, path, payload, contentType)
+		if err != nil {
+			return nil, err
+		}
+		fields := map[string]interface{}{
+			"http_method": method,
+			"http_path":   path,
+			"attempt":     attempt + 1,
+		}
+		tflog.SubsystemTrace(ctx, SubsystemClient, "Sending Robot API request", fields, map[string]interface{}{
+			"http_request_body": string(payload),
+		})
+		start := time.Now()
+		resp, err := c.Client.Do(req)
+		c.audit.record(ctx, c, method, path, payload, attempt, start, resp, err)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			if resp != nil {
+				resp.Body.Close()
+			}
+			return nil, ctxErr
+		}
+		if err == nil {
+			fields["http_status"] = resp.StatusCode
+			fields["duration_ms"] = time.Since(start).Milliseconds()
+			tflog.SubsystemTrace(ctx, SubsystemClient, "Received Robot API response", fields, map[string]interface{}{
+				"http_response_body": peekBody(resp),
+			})
+		}
+		retry, wait := c.checkRetry(method, path, attempt, resp, err)
+		if !retry || attempt >= c.Config.MaxRetries {
+			if err != nil {
+				return nil, fmt.Errorf("error making request: %w", err)
+			}
+			if method != http.MethodGet && invalidatesServerCache(path) {
+				c.InvalidateServerCache()
+			}
+			return resp, nil
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+		warnFields := map[string]interface{}{"retry_in": wait.String()}
+		if err != nil {
+			warnFields["error"] = err.Error()
+		}
+		tflog.SubsystemWarn(ctx, SubsystemClient, "Retrying Robot API request", fields, warnFields)
+		if err := sleepContext(ctx, wait); err != nil {
+			return nil, err
+		}
 	}
 }
 
-func (c *HetznerRobotClient) DoRequest(method, path string, body io.Reader, contentType string) (*http.Response, error) {
-	req, err := http.NewRequest(method, fmt.Sprintf("%s%s", c.Config.BaseURL, path), body)
+func (c *HetznerRobotClient) newRequest(ctx context.Context, method, path string, payload []byte, contentType string) (*http.Request, error) {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, fmt.Sprintf("%s%s", c.Config.BaseURL, path), body)
 	if err != nil {
 		return nil, fmt.Errorf("error creating request: %w", err)
 	}
 	req.SetBasicAuth(c.Config.Username, c.Config.Password)
+	req.Header.Set("User-Agent", c.Config.UserAgent)
 	if contentType != "" {
 		req.Header.Set("Content-Type", contentType)
 	}
-	resp, err := c.Client.Do(req)
+	return req, nil
+}
+
+// checkWritable rejects every request but GET in read-only mode.
+func (c *HetznerRobotClient) checkWritable(method, path string) error {
+	if c.Config.ReadOnly && method != http.MethodGet {
+		return fmt.Errorf("%w: refusing to send %s %s", ErrReadOnly, method, path)
+	}
+	return nil
+}
+
+// TimeLeft returns the time until the deadline of ctx, or fallback if it has
+// none.
+func TimeLeft(ctx context.Context, fallback time.Duration) time.Duration {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return fallback
+	}
+	return max(time.Until(deadline), 0)
+}
+
+// sleepContext pauses for d or until ctx is done, whichever comes first.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// peekBody returns the response body as a string and replaces it with an
+// unread copy, so that it can be logged without consuming it.
+func peekBody(resp *http.Response) string {
+	bodyBytes, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(bodyBytes))
 	if err != nil {
-		return nil, fmt.Errorf("error making request: %w", err)
+		return ""
+	}
+	return string(bodyBytes)
+}
+
+// invalidatesServerCache reports whether a mutating request to path can change
+// the server listing, e.g. a rename, a cancellation or a new order.
+func invalidatesServerCache(path string) bool {
+	switch endpointFamily(path) {
+	case "server", "order":
+		return true
 	}
-	return resp, nil
+	return false
 }
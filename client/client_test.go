@@ -0,0 +1,356 @@
+package client_test
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/client"
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/robotfake"
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/shared"
+)
+
+func newTestClient(t *testing.T, fake *robotfake.Fake, maxRetries int) *client.HetznerRobotClient {
+	t.Helper()
+	return fake.NewClient(t, func(c *shared.ProviderConfig) {
+		c.MaxRetries = maxRetries
+	})
+}
+
+func countRequests(fake *robotfake.Fake, method, path string) int {
+	n := 0
+	for _, r := range fake.Requests() {
+		if r.Method == method && r.Path == path {
+			n++
+		}
+	}
+	return n
+}
+
+func TestDoRequestRetriesRateLimitedRequests(t *testing.T) {
+	fake := robotfake.New(robotfake.Options{})
+	defer fake.Close()
+	fake.AddServer(robotfake.Server{Number: 321, IP: "203.0.113.10"})
+	fake.RateLimitNext("GET", "/server/321", 2, "0")
+
+	c := newTestClient(t, fake, 3)
+	srv, err := c.Servers().Fetch(context.Background(), 321)
+	if err != nil {
+		t.Fatalf("Servers().Fetch: %v", err)
+	}
+	if srv.IP != "203.0.113.10" {
+		t.Errorf("IP = %q, want 203.0.113.10", srv.IP)
+	}
+	if got := countRequests(fake, "GET", "/server/321"); got != 3 {
+		t.Errorf("sent %d requests, want 3", got)
+	}
+}
+
+func TestDoRequestGivesUpAfterMaxRetries(t *testing.T) {
+	fake := robotfake.New(robotfake.Options{})
+	defer fake.Close()
+	fake.AddServer(robotfake.Server{Number: 321, IP: "203.0.113.10"})
+	fake.RateLimitNext("GET", "/server/321", 10, "")
+
+	c := newTestClient(t, fake, 2)
+	_, err := c.Servers().Fetch(context.Background(), 321)
+	if !client.IsRateLimited(err) {
+		t.Fatalf("err = %v, want rate limit error", err)
+	}
+	if got := countRequests(fake, "GET", "/server/321"); got != 3 {
+		t.Errorf("sent %d requests, want 3", got)
+	}
+}
+
+func TestDoRequestDoesNotRetryFailedMutations(t *testing.T) {
+	fake := robotfake.New(robotfake.Options{})
+	defer fake.Close()
+	fake.AddServer(robotfake.Server{Number: 321, IP: "203.0.113.10", Reset: true})
+	fake.FailNext("POST", "/reset/321", 1, 500, "INTERNAL_ERROR", "Internal error")
+
+	c := newTestClient(t, fake, 3)
+	if err := c.Reset().Reset(context.Background(), 321, "hw"); err == nil {
+		t.Fatal("Reset() succeeded, want error")
+	}
+	if got := len(fake.Resets()); got != 0 {
+		t.Errorf("executed %d resets, want 0", got)
+	}
+	if got := countRequests(fake, "POST", "/reset/321"); got != 1 {
+		t.Errorf("sent %d requests, want 1", got)
+	}
+}
+
+func TestDoRequestRetriesOrdersOnlyWhenRateLimited(t *testing.T) {
+	fake := robotfake.New(robotfake.Options{})
+	defer fake.Close()
+	fake.AddServerProduct(robotfake.ServerProduct{ID: "EX44", Name: "EX44", Locations: []string{"FSN1"}, MonthlyNet: "44.0000"})
+	c := newTestClient(t, fake, 3)
+	order := client.ServerOrder{ProductID: "EX44", Password: "secret", Test: true}
+
+	// A 503 may come from a proxy after Robot accepted the order.
+	fake.FailNext("POST", "/order/server/transaction", 1, 503, "MAINTENANCE", "Service unavailable")
+	if _, err := c.Ordering().OrderServer(context.Background(), order); err == nil {
+		t.Fatal("OrderServer() succeeded, want error")
+	}
+	if got := countRequests(fake, "POST", "/order/server/transaction"); got != 1 {
+		t.Errorf("sent %d order requests after a 503, want 1", got)
+	}
+
+	fake.FailNext("POST", "/order/server/transaction", 1, 429, "RATE_LIMIT_EXCEEDED", "Rate limit exceeded")
+	if _, err := c.Ordering().OrderServer(context.Background(), order); err != nil {
+		t.Fatalf("OrderServer() after a rate limit: %v", err)
+	}
+	if got := countRequests(fake, "POST", "/order/server/transaction"); got != 3 {
+		t.Errorf("sent %d order requests in total, want 3", got)
+	}
+}
+
+func TestRobotErrorClassification(t *testing.T) {
+	fake := robotfake.New(robotfake.Options{})
+	defer fake.Close()
+	fake.AddServer(robotfake.Server{Number: 321, IP: "203.0.113.10"})
+	c := newTestClient(t, fake, 0)
+	ctx := context.Background()
+
+	_, err := c.VSwitches().Get(ctx, 4711)
+	if !client.IsNotFound(err) {
+		t.Errorf("VSwitches().Get: err = %v, want not found", err)
+	}
+
+	vsw, err := c.VSwitches().Create(ctx, "test", 4001)
+	if err != nil {
+		t.Fatalf("VSwitches().Create: %v", err)
+	}
+	_, err = c.VSwitches().Create(ctx, "dup", 4001)
+	if err == nil || client.IsConflict(err) {
+		t.Errorf("duplicate VLAN: err = %v, want permanent error", err)
+	}
+
+	id := vsw.ID
+	if err := c.VSwitches().AddServers(ctx, id, []client.VSwitchServer{{ServerNumber: 321}}); err != nil {
+		t.Fatalf("VSwitches().AddServers: %v", err)
+	}
+	err = c.VSwitches().Update(ctx, id, "renamed", 4002, 4001)
+	if !client.IsConflict(err) {
+		t.Errorf("VSwitches().Update while processing: err = %v, want conflict", err)
+	}
+}
+
+func TestServerLookupsShareOneListing(t *testing.T) {
+	fake := robotfake.New(robotfake.Options{})
+	defer fake.Close()
+	for i := 1; i <= 5; i++ {
+		fake.AddServer(robotfake.Server{Number: i, Name: "node", IP: "203.0.113." + strconv.Itoa(i)})
+	}
+	c := newTestClient(t, fake, 0)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 1; i <= 5; i++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			if _, err := c.Servers().Get(ctx, n); err != nil {
+				t.Errorf("Servers().Get(%d): %v", n, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if _, err := c.Servers().GetByIP(ctx, "203.0.113.3"); err != nil {
+		t.Errorf("Servers().GetByIP: %v", err)
+	}
+	if got := countRequests(fake, "GET", "/server"); got != 1 {
+		t.Errorf("sent %d listing requests, want 1", got)
+	}
+
+	if _, err := c.Servers().Get(ctx, 99); !client.IsNotFound(err) {
+		t.Errorf("Servers().Get(99): err = %v, want not found", err)
+	}
+
+	if _, err := c.Servers().Rename(ctx, 1, "renamed"); err != nil {
+		t.Fatalf("RenameServer: %v", err)
+	}
+	srv, err := c.Servers().Get(ctx, 1)
+	if err != nil {
+		t.Fatalf("Servers().Get after rename: %v", err)
+	}
+	if srv.ServerName != "renamed" {
+		t.Errorf("ServerName = %q, want renamed", srv.ServerName)
+	}
+}
+
+func TestServerLookupsSurviveConcurrentInvalidation(t *testing.T) {
+	fake := robotfake.New(robotfake.Options{})
+	defer fake.Close()
+	for i := 1; i <= 5; i++ {
+		fake.AddServer(robotfake.Server{Number: i, Name: "node", IP: "203.0.113." + strconv.Itoa(i)})
+	}
+	c := newTestClient(t, fake, 0)
+	ctx := context.Background()
+
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				c.InvalidateServerCache()
+			}
+		}
+	}()
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				if _, err := c.Servers().Get(ctx, n); err != nil {
+					t.Errorf("Servers().Get(%d): %v", n, err)
+					return
+				}
+				if _, err := c.Servers().GetByIP(ctx, "203.0.113."+strconv.Itoa(n)); err != nil {
+					t.Errorf("Servers().GetByIP: %v", err)
+					return
+				}
+				if servers, err := c.Servers().ListByName(ctx, "node"); err != nil || len(servers) != 5 {
+					t.Errorf("Servers().ListByName = %d servers, %v, want 5", len(servers), err)
+					return
+				}
+			}
+		}(i%5 + 1)
+	}
+	wg.Wait()
+	close(done)
+}
+
+func TestDoRequestHonoursContextCancellation(t *testing.T) {
+	fake := robotfake.New(robotfake.Options{})
+	defer fake.Close()
+	fake.AddServer(robotfake.Server{Number: 321, IP: "203.0.113.10"})
+	fake.RateLimitNext("GET", "/server/321", 1, "3600")
+
+	c := newTestClient(t, fake, 3)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := c.Servers().Fetch(ctx, 321)
+	if err == nil {
+		t.Fatal("Servers().Fetch succeeded, want context error")
+	}
+	if ctx.Err() == nil || time.Since(start) > 5*time.Second {
+		t.Errorf("request was not interrupted by the context: %v", err)
+	}
+}
+
+func TestReadOnlyRejectsMutationsBeforeSending(t *testing.T) {
+	fake := robotfake.New(robotfake.Options{})
+	defer fake.Close()
+	fake.AddServer(robotfake.Server{Number: 321, IP: "203.0.113.10", Reset: true})
+
+	c := newTestClient(t, fake, 0)
+	c.Config.ReadOnly = true
+	ctx := context.Background()
+
+	if _, err := c.Servers().Fetch(ctx, 321); err != nil {
+		t.Fatalf("Servers().Fetch in read-only mode: %v", err)
+	}
+	if err := c.Reset().Reset(ctx, 321, "hw"); !client.IsReadOnly(err) {
+		t.Errorf("Reset() error = %v, want read-only error", err)
+	}
+	if err := c.VSwitches().Delete(ctx, 1001, "now"); !client.IsReadOnly(err) {
+		t.Errorf("DeleteVSwitch error = %v, want read-only error", err)
+	}
+	if err := c.Installer().InstallTalos(ctx, "203.0.113.10", "secret"); !client.IsReadOnly(err) {
+		t.Errorf("InstallTalos error = %v, want read-only error", err)
+	}
+	for _, r := range fake.Requests() {
+		if r.Method != "GET" {
+			t.Errorf("sent %s %s in read-only mode", r.Method, r.Path)
+		}
+	}
+}
+
+func TestServersFetchDecodesDetail(t *testing.T) {
+	fake := robotfake.New(robotfake.Options{})
+	defer fake.Close()
+	storagebox := 42
+	fake.AddServer(robotfake.Server{Number: 321, IP: "203.0.113.10", IPv6Net: "2001:db8:1::", Reset: true, WOL: true, LinkedStoragebox: &storagebox})
+	c := newTestClient(t, fake, 0)
+
+	srv, err := c.Servers().Fetch(context.Background(), 321)
+	if err != nil {
+		t.Fatalf("Servers().Fetch: %v", err)
+	}
+	if len(srv.IPs) != 1 || srv.IPs[0] != "203.0.113.10" {
+		t.Errorf("IPs = %v", srv.IPs)
+	}
+	if len(srv.Subnets) != 1 || srv.Subnets[0] != (client.ServerSubnet{IP: "2001:db8:1::", Mask: "64"}) {
+		t.Errorf("Subnets = %v", srv.Subnets)
+	}
+	if !srv.Reset || !srv.WOL || srv.VNC {
+		t.Errorf("capabilities reset=%v wol=%v vnc=%v, want true, true, false", srv.Reset, srv.WOL, srv.VNC)
+	}
+	if srv.LinkedStoragebox == nil || *srv.LinkedStoragebox != 42 {
+		t.Errorf("LinkedStoragebox = %v, want 42", srv.LinkedStoragebox)
+	}
+}
+
+func TestServerCancellation(t *testing.T) {
+	fake := robotfake.New(robotfake.Options{})
+	defer fake.Close()
+	fake.AddServer(robotfake.Server{Number: 321, IP: "203.0.113.10", PaidUntil: "2030-01-31"})
+	c := newTestClient(t, fake, 0)
+	ctx := context.Background()
+
+	options, err := c.Servers().Cancellation(ctx, 321)
+	if err != nil {
+		t.Fatalf("Servers().Cancellation: %v", err)
+	}
+	if options.Cancelled || options.EarliestCancellationDate != "2030-01-31" || len(options.Reasons) == 0 {
+		t.Fatalf("options = %+v", options)
+	}
+
+	if _, err := c.Servers().Cancel(ctx, 321, client.CancelRequest{Date: "2030-01-01"}); err == nil {
+		t.Error("cancelling before the earliest date succeeded")
+	}
+	cancellation, err := c.Servers().Cancel(ctx, 321, client.CancelRequest{Date: "2030-01-31", Reason: options.Reasons[0]})
+	if err != nil {
+		t.Fatalf("Servers().Cancel: %v", err)
+	}
+	if !cancellation.Cancelled || cancellation.CancellationDate != "2030-01-31" || cancellation.Reason != options.Reasons[0] {
+		t.Errorf("cancellation = %+v", cancellation)
+	}
+
+	if err := c.Servers().RevokeCancellation(ctx, 321); err != nil {
+		t.Fatalf("Servers().RevokeCancellation: %v", err)
+	}
+	if srv, _ := fake.Server(321); srv.Cancelled {
+		t.Error("server still cancelled after revoking")
+	}
+}
+
+func TestServerReversal(t *testing.T) {
+	fake := robotfake.New(robotfake.Options{})
+	defer fake.Close()
+	fake.AddServer(robotfake.Server{Number: 321, IP: "203.0.113.10", ReversalPossible: true})
+	fake.AddServer(robotfake.Server{Number: 322, IP: "203.0.113.11"})
+	c := newTestClient(t, fake, 0)
+	ctx := context.Background()
+
+	if r, err := c.Servers().Reversal(ctx, 322); err != nil || r.ReversalPossible {
+		t.Errorf("Servers().Reversal(322) = %+v, %v, want not possible", r, err)
+	}
+	if _, err := c.Servers().Reverse(ctx, 322, ""); !client.IsConflict(err) {
+		t.Errorf("Servers().Reverse(322): err = %v, want conflict", err)
+	}
+	r, err := c.Servers().Reverse(ctx, 321, "ordered by mistake")
+	if err != nil {
+		t.Fatalf("Servers().Reverse(321): %v", err)
+	}
+	if !r.Reversed || r.ReversalPossible || r.ReversalReason != "ordered by mistake" {
+		t.Errorf("reversal = %+v", r)
+	}
+}
@@ -0,0 +1,114 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+var (
+	ErrNotFound    = errors.New("not found")
+	ErrConflict    = errors.New("conflict")
+	ErrRateLimited = errors.New("rate limited")
+
+	// ErrReadOnly is returned for every mutating call while the provider
+	// is in read-only mode. Nothing is sent to the Robot webservice.
+	ErrReadOnly = errors.New("provider is in read-only mode")
+)
+
+// IsReadOnly reports whether err was caused by read-only mode.
+func IsReadOnly(err error) bool {
+	return errors.Is(err, ErrReadOnly)
+}
+
+// RobotError is the structured error returned by the Robot webservice:
+//
+//	{"error":{"status":404,"code":"SERVER_NOT_FOUND","message":"Server not found"}}
+type RobotError struct {
+	StatusCode int      `json:"status"`
+	Code       string   `json:"code"`
+	Message    string   `json:"message"`
+	Missing    []string `json:"missing"`
+	Invalid    []string `json:"invalid"`
+
+	// Body holds the raw response body when it could not be decoded.
+	Body string `json:"-"`
+}
+
+func (e *RobotError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "robot API error: status %d", e.StatusCode)
+	if e.Code != "" {
+		fmt.Fprintf(&b, ", code %s", e.Code)
+	}
+	if e.Message != "" {
+		fmt.Fprintf(&b, ": %s", e.Message)
+	}
+	if len(e.Missing) > 0 {
+		fmt.Fprintf(&b, " (missing: %s)", strings.Join(e.Missing, ", "))
+	}
+	if len(e.Invalid) > 0 {
+		fmt.Fprintf(&b, " (invalid: %s)", strings.Join(e.Invalid, ", "))
+	}
+	if e.Code == "" && e.Message == "" && e.Body != "" {
+		fmt.Fprintf(&b, ", body %s", e.Body)
+	}
+	return b.String()
+}
+
+// Is lets errors.Is match a RobotError against ErrNotFound, ErrConflict and
+// ErrRateLimited.
+func (e *RobotError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound || strings.HasSuffix(e.Code, "NOT_FOUND")
+	case ErrConflict:
+		return e.Code == "CONFLICT" || strings.HasSuffix(e.Code, "_IN_PROCESS") ||
+			(e.StatusCode == http.StatusConflict && e.Code == "")
+	case ErrRateLimited:
+		return e.Code == "RATE_LIMIT_EXCEEDED" || e.StatusCode == http.StatusTooManyRequests
+	}
+	return false
+}
+
+// IsNotFound reports whether err means the requested object does not exist.
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrNotFound)
+}
+
+// IsConflict reports whether err means the object is still busy with a
+// previous operation (e.g. VSWITCH_IN_PROCESS), a condition that clears by
+// itself and is worth retrying. Permanent conflicts such as
+// VSWITCH_VLAN_NOT_UNIQUE are not reported as conflicts.
+func IsConflict(err error) bool {
+	return errors.Is(err, ErrConflict)
+}
+
+// IsRateLimited reports whether err means the Robot request quota was exceeded.
+func IsRateLimited(err error) bool {
+	return errors.Is(err, ErrRateLimited)
+}
+
+// newRobotError reads the body of an unsuccessful response and turns it into
+// a *RobotError.
+func newRobotError(resp *http.Response) error {
+	bodyBytes, _ := io.ReadAll(resp.Body)
+	return parseRobotError(resp.StatusCode, bodyBytes)
+}
+
+func parseRobotError(statusCode int, body []byte) *RobotError {
+	var envelope struct {
+		Error *RobotError `json:"error"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil || envelope.Error == nil {
+		return &RobotError{StatusCode: statusCode, Body: strings.TrimSpace(string(body))}
+	}
+	robotErr := envelope.Error
+	if robotErr.StatusCode == 0 {
+		robotErr.StatusCode = statusCode
+	}
+	return robotErr
+}
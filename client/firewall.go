@@ -4,21 +4,21 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"net/url"
-	"strings"
 	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 )
 
-func (c *HetznerRobotClient) GetFirewall(ctx context.Context, ip string) (*HetznerRobotFirewall, error) {
+func (c firewallService) Get(ctx context.Context, ip string) (*HetznerRobotFirewall, error) {
 	path := fmt.Sprintf("/firewall/%s", ip)
-	resp, err := c.DoRequest("GET", path, nil, "")
+	resp, err := c.DoRequest(ctx, "GET", path, nil, "")
 	if err != nil {
 		return nil, fmt.Errorf("failed to get firewall: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != 200 && resp.StatusCode != 202 {
-		return nil, fmt.Errorf("unexpected response status: %d", resp.StatusCode)
+		return nil, fmt.Errorf("failed to get firewall: %w", newRobotError(resp))
 	}
 
 	var fwResp HetznerRobotFirewallResponse
@@ -29,71 +29,46 @@ func (c *HetznerRobotClient) GetFirewall(ctx context.Context, ip string) (*Hetzn
 	return &fwResp.Firewall, nil
 }
 
-func (c *HetznerRobotClient) SetFirewall(ctx context.Context, firewall HetznerRobotFirewall, maxRetries int, waitTime time.Duration) error {
+func (c firewallService) Set(ctx context.Context, firewall HetznerRobotFirewall, timeout time.Duration) error {
 	path := fmt.Sprintf("/firewall/%s", firewall.IP)
-	whitelistHOS := "false"
-	if firewall.WhitelistHetznerServices {
-		whitelistHOS = "true"
-	}
-
-	data := url.Values{}
-	data.Set("whitelist_hos", whitelistHOS)
-	data.Set("status", firewall.Status)
-
-	for idx, rule := range firewall.Rules.Input {
-		data.Set(fmt.Sprintf("rules[input][%d][ip_version]", idx), "ipv4")
-		if rule.Name != "" {
-			data.Set(fmt.Sprintf("rules[input][%d][name]", idx), rule.Name)
-		}
-		if rule.DstIP != "" {
-			data.Set(fmt.Sprintf("rules[input][%d][dst_ip]", idx), rule.DstIP)
-		}
-		if rule.DstPort != "" {
-			data.Set(fmt.Sprintf("rules[input][%d][dst_port]", idx), rule.DstPort)
-		}
-		if rule.SrcIP != "" {
-			data.Set(fmt.Sprintf("rules[input][%d][src_ip]", idx), rule.SrcIP)
-		}
-		if rule.SrcPort != "" {
-			data.Set(fmt.Sprintf("rules[input][%d][src_port]", idx), rule.SrcPort)
-		}
-		if rule.Protocol != "" {
-			data.Set(fmt.Sprintf("rules[input][%d][protocol]", idx), rule.Protocol)
-		}
-		if rule.TCPFlags != "" {
-			data.Set(fmt.Sprintf("rules[input][%d][tcp_flags]", idx), rule.TCPFlags)
-		}
-		data.Set(fmt.Sprintf("rules[input][%d][action]", idx), rule.Action)
-	}
-
-	resp, err := c.DoRequest("POST", path, strings.NewReader(data.Encode()), "application/x-www-form-urlencoded")
+	resp, err := c.doForm(ctx, "POST", path, firewall)
 	if err != nil {
 		return fmt.Errorf("failed to set firewall: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != 200 && resp.StatusCode != 202 {
-		return fmt.Errorf("unexpected response status: %d", resp.StatusCode)
+		return fmt.Errorf("failed to set firewall: %w", newRobotError(resp))
 	}
 
-	return c.waitForFirewallActive(ctx, firewall.IP, maxRetries, waitTime)
+	return c.waitForFirewall(ctx, firewall.IP, timeout)
 }
 
-func (c *HetznerRobotClient) waitForFirewallActive(ctx context.Context, ip string, maxRetries int, waitTime time.Duration) error {
-	for i := 0; i < maxRetries; i++ {
-		firewall, err := c.GetFirewall(ctx, ip)
+// waitForFirewall polls until Robot has applied the last firewall change,
+// i.e. until the status is no longer "in process".
+func (c firewallService) waitForFirewall(ctx context.Context, ip string, timeout time.Duration) error {
+	ctx = c.LogContext(ctx, SubsystemFirewall)
+	poller := c.newPoller("firewall of "+ip+" to be applied", func(p PollProgress) {
+		tflog.SubsystemDebug(ctx, SubsystemFirewall, "Waiting for firewall to be applied", map[string]interface{}{
+			"server_ip": ip,
+			"status":    p.Status,
+			"attempt":   p.Attempt,
+			"retry_in":  p.Next.String(),
+		})
+	})
+	poller.Timeout = timeout
+	return poller.Poll(ctx, func(ctx context.Context) (bool, string, error) {
+		firewall, err := c.Get(ctx, ip)
 		if err != nil {
-			return fmt.Errorf("error checking firewall status: %w", err)
+			return false, "", fmt.Errorf("error checking firewall status: %w", err)
 		}
-
-		if firewall.Status == "active" {
-			fmt.Println("Firewall is now active.")
-			return nil
+		if firewall.Status == "in process" {
+			return false, firewall.Status, nil
 		}
-
-		fmt.Printf("Waiting for firewall to become active... (%d/%d)\n", i+1, maxRetries)
-		time.Sleep(waitTime)
-	}
-
-	return fmt.Errorf("timeout waiting for firewall to become active")
+		tflog.SubsystemDebug(ctx, SubsystemFirewall, "Firewall is applied", map[string]interface{}{
+			"server_ip": ip,
+			"status":    firewall.Status,
+		})
+		return true, firewall.Status, nil
+	})
 }
@@ -0,0 +1,130 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const formContentType = "application/x-www-form-urlencoded"
+
+// EncodeForm marshals a struct into the PHP-style bracket notation the Robot
+// webservice expects. Fields are named by their `form` tag:
+//
+//   - nested structs become name[field]
+//   - slices of structs become name[0][field], name[1][field], ...
+//   - slices of scalars become repeated name[] keys
+//   - booleans are encoded as "true" and "false"
+//   - nil pointers are omitted, and so are zero values tagged omitempty
+//   - fields tagged "-" and untagged fields are ignored
+//
+// The result is encoded with url.Values.Encode, i.e. with sorted keys.
+func EncodeForm(v interface{}) (url.Values, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return url.Values{}, nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("form: cannot encode %s, want a struct", rv.Type())
+	}
+	values := url.Values{}
+	if err := encodeStruct(values, "", rv); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func formKey(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "[" + name + "]"
+}
+
+func encodeStruct(values url.Values, prefix string, rv reflect.Value) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		tag, ok := field.Tag.Lookup("form")
+		if !ok || tag == "-" || !field.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		fv := rv.Field(i)
+		if opts == "omitempty" && fv.IsZero() {
+			continue
+		}
+		if err := encodeValue(values, formKey(prefix, name), fv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func encodeValue(values url.Values, key string, fv reflect.Value) error {
+	if fv.Kind() == reflect.Pointer {
+		if fv.IsNil() {
+			return nil
+		}
+		fv = fv.Elem()
+	}
+	switch fv.Kind() {
+	case reflect.Struct:
+		return encodeStruct(values, key, fv)
+	case reflect.Slice, reflect.Array:
+		elem := fv.Type().Elem()
+		if elem.Kind() == reflect.Pointer {
+			elem = elem.Elem()
+		}
+		for i := 0; i < fv.Len(); i++ {
+			var err error
+			if elem.Kind() == reflect.Struct {
+				err = encodeValue(values, key+"["+strconv.Itoa(i)+"]", fv.Index(i))
+			} else {
+				err = encodeValue(values, key+"[]", fv.Index(i))
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	s, err := formScalar(fv)
+	if err != nil {
+		return fmt.Errorf("form: field %s: %w", key, err)
+	}
+	values.Add(key, s)
+	return nil
+}
+
+func formScalar(fv reflect.Value) (string, error) {
+	switch fv.Kind() {
+	case reflect.String:
+		return fv.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(fv.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(fv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(fv.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(fv.Float(), 'f', -1, 64), nil
+	}
+	return "", fmt.Errorf("unsupported type %s", fv.Type())
+}
+
+// doForm sends form, encoded with EncodeForm, as the body of a request.
+func (c *HetznerRobotClient) doForm(ctx context.Context, method, path string, form interface{}) (*http.Response, error) {
+	values, err := EncodeForm(form)
+	if err != nil {
+		return nil, err
+	}
+	return c.DoRequest(ctx, method, path, strings.NewReader(values.Encode()), formContentType)
+}
@@ -0,0 +1,112 @@
+package client
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata/form")
+
+// TestEncodeFormGolden pins the exact request bodies sent to Robot. The golden
+// files were recorded from the hand-built url.Values encoders the request
+// builders used before EncodeForm.
+func TestEncodeFormGolden(t *testing.T) {
+	cases := map[string]interface{}{
+		"firewall_rules": HetznerRobotFirewall{
+			IP:                       "203.0.113.10",
+			WhitelistHetznerServices: true,
+			Status:                   "active",
+			Rules: HetznerRobotFirewallRules{Input: []HetznerRobotFirewallRule{
+				{IPVersion: "ipv4", Name: "allow ssh", SrcIP: "10.0.0.0/8", SrcPort: "1024-65535", DstIP: "203.0.113.10/32", DstPort: "22", Protocol: "tcp", TCPFlags: "syn", Action: "accept"},
+				{IPVersion: "ipv4", Name: "drop rest", Action: "discard"},
+				{IPVersion: "ipv4", Action: "accept"},
+			}},
+		},
+		"firewall_disabled":    HetznerRobotFirewall{IP: "203.0.113.10", Status: "disabled"},
+		"rescue_keys":          rescueRequest{OS: "linux", AuthorizedKeys: []string{"ssh-ed25519 AAAA key1", "ssh-rsa BBBB key2+/="}},
+		"rescue_no_keys":       rescueRequest{OS: "vkvm"},
+		"reset":                resetRequest{Type: "hw"},
+		"rename":               renameRequest{ServerName: "node 1/ü & co"},
+		"vswitch_create":       vswitchRequest{Name: "private net", VLAN: intPointer(4000)},
+		"vswitch_create_vlan0": vswitchRequest{Name: "private net", VLAN: intPointer(0)},
+		"vswitch_update_vlan":  vswitchRequest{Name: "renamed", VLAN: intPointer(4001)},
+		"vswitch_update_name":  vswitchRequest{Name: "renamed"},
+		"vswitch_servers":      newVSwitchServersRequest([]VSwitchServer{{ServerNumber: 321}, {ServerNumber: 42}, {ServerNumber: 1000}}),
+		"vswitch_cancel":       cancellationRequest{CancellationDate: "2030-12-31"},
+	}
+	for name, form := range cases {
+		t.Run(name, func(t *testing.T) {
+			values, err := EncodeForm(form)
+			if err != nil {
+				t.Fatalf("EncodeForm: %v", err)
+			}
+			got := values.Encode() + "\n"
+			path := filepath.Join("testdata", "form", name+".golden")
+			if *updateGolden {
+				if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != string(want) {
+				t.Errorf("body mismatch\n got: %s\nwant: %s", got, want)
+			}
+		})
+	}
+}
+
+func TestEncodeFormTypes(t *testing.T) {
+	type inner struct {
+		Flag  bool   `form:"flag"`
+		Label string `form:"label,omitempty"`
+	}
+	yes := true
+	form := struct {
+		Count    int     `form:"count"`
+		Price    float64 `form:"price"`
+		Optional *bool   `form:"optional"`
+		Missing  *string `form:"missing"`
+		Zero     int     `form:"zero,omitempty"`
+		Skipped  string  `form:"-"`
+		Untagged string
+		Nested   inner    `form:"nested"`
+		Items    []*inner `form:"items"`
+		Tags     []string `form:"tags"`
+	}{
+		Count:    3,
+		Price:    12.5,
+		Optional: &yes,
+		Skipped:  "x",
+		Untagged: "y",
+		Nested:   inner{Label: "a b"},
+		Items:    []*inner{{Flag: true}, {Label: "second"}},
+		Tags:     []string{"b", "a"},
+	}
+	values, err := EncodeForm(&form)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "count=3&items%5B0%5D%5Bflag%5D=true&items%5B1%5D%5Bflag%5D=false&items%5B1%5D%5Blabel%5D=second" +
+		"&nested%5Bflag%5D=false&nested%5Blabel%5D=a+b&optional=true&price=12.5&tags%5B%5D=b&tags%5B%5D=a"
+	if got := values.Encode(); got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+
+	if _, err := EncodeForm(struct {
+		M map[string]string `form:"m"`
+	}{M: map[string]string{}}); err == nil {
+		t.Error("encoded a map")
+	}
+	if _, err := EncodeForm("not a struct"); err == nil {
+		t.Error("encoded a string")
+	}
+}
+
+func intPointer(i int) *int {
+	return &i
+}
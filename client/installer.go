@@ -0,0 +1,158 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"golang.org/x/crypto/ssh"
+)
+
+const talosImageURL = "https://factory.talos.dev/image/3531bf15c8738b4bc46f2cdd7c5cd68fea388796b291117f0ee38b51a335fc47/v1.9.2/metal-amd64.raw.zst"
+
+// talosDisks are wiped before Talos is installed, which is written to the
+// first of them.
+var talosDisks = []string{"/dev/nvme0n1", "/dev/nvme1n1"}
+
+// rescueSession runs commands one at a time on the rescue system of a server.
+// Every command gets its own SSH session so that a failure can be attributed
+// to the step that caused it.
+type rescueSession struct {
+	conn     *ssh.Client
+	serverIP string
+	password string
+}
+
+func (c *HetznerRobotClient) openRescueSession(ctx context.Context, serverIP, password string) (*rescueSession, error) {
+	if c.Config.ReadOnly {
+		return nil, fmt.Errorf("%w: refusing to run commands on %s", ErrReadOnly, serverIP)
+	}
+	conn, err := c.dialSSH(ctx, serverIP, password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to SSH to %s: %w", serverIP, err)
+	}
+	return &rescueSession{conn: conn, serverIP: serverIP, password: password}, nil
+}
+
+func (s *rescueSession) Close() error {
+	return s.conn.Close()
+}
+
+// run executes cmd and returns its output. Output is redacted before it is
+// included in an error.
+func (s *rescueSession) run(ctx context.Context, cmd string) (string, error) {
+	tflog.SubsystemDebug(ctx, SubsystemInstaller, "Running rescue command", map[string]interface{}{
+		"server_ip": s.serverIP,
+		"command":   cmd,
+	})
+	output, err := runSSHCommand(ctx, s.conn, cmd)
+	tflog.SubsystemTrace(ctx, SubsystemInstaller, "Rescue command finished", map[string]interface{}{
+		"server_ip": s.serverIP,
+		"command":   cmd,
+		"output":    string(output),
+	})
+	if err != nil {
+		return string(output), fmt.Errorf("command %q failed on %s: %s\nerror: %w", cmd, s.serverIP, redactSecrets(string(output), s.password), err)
+	}
+	return string(output), nil
+}
+
+// disks returns the device paths of the physical disks of the rescue system,
+// in the order reported by lsblk.
+func (s *rescueSession) disks(ctx context.Context) ([]string, error) {
+	out, err := s.run(ctx, "lsblk -dn -o NAME,TYPE")
+	if err != nil {
+		return nil, err
+	}
+	var disks []string
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[1] == "disk" {
+			disks = append(disks, "/dev/"+fields[0])
+		}
+	}
+	if len(disks) == 0 {
+		return nil, fmt.Errorf("no disks found on %s", s.serverIP)
+	}
+	return disks, nil
+}
+
+// wipe stops the software RAID and removes every signature from disks.
+func (s *rescueSession) wipe(ctx context.Context, disks []string) error {
+	cmds := []string{
+		"mdadm --stop /dev/md0 || true",
+		"mdadm --remove /dev/md0 || true",
+		"mdadm --zero-superblock " + strings.Join(disks, " ") + " || true",
+	}
+	for _, disk := range disks {
+		cmds = append(cmds,
+			"wipefs --all --force "+disk,
+			"dd if=/dev/zero of="+disk+" bs=1M count=10 || true",
+		)
+	}
+	for _, cmd := range cmds {
+		if _, err := s.run(ctx, cmd); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reboot restarts the server. The rescue system usually drops the connection
+// before reporting an exit status, which is not an error.
+func (s *rescueSession) reboot(ctx context.Context) error {
+	if _, err := s.run(ctx, "reboot"); err != nil && (ctx.Err() != nil || !isDisconnect(err)) {
+		return err
+	}
+	return nil
+}
+
+// InstallTalos wipes both NVMe disks of a server booted into the rescue
+// system, writes the Talos image to /dev/nvme0n1 and reboots into it.
+func (c installerService) InstallTalos(ctx context.Context, serverIP, password string) error {
+	ctx = MaskSecret(c.LogContext(ctx, SubsystemInstaller), password)
+	tflog.SubsystemInfo(ctx, SubsystemInstaller, "Installing Talos OS", map[string]interface{}{"server_ip": serverIP})
+	s, err := c.openRescueSession(ctx, serverIP, password)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	if err := s.wipe(ctx, talosDisks); err != nil {
+		return err
+	}
+	for _, cmd := range []string{
+		"wget " + talosImageURL + " -O talos.raw.zst",
+		"zstd -d talos.raw.zst -o talos.raw",
+		"dd if=talos.raw of=" + talosDisks[0] + " bs=4M status=progress",
+		"sync",
+	} {
+		if _, err := s.run(ctx, cmd); err != nil {
+			return err
+		}
+	}
+	return s.reboot(ctx)
+}
+
+// WipeDisks removes every signature from all disks of a server booted into
+// the rescue system.
+func (c installerService) WipeDisks(ctx context.Context, serverIP, password string) error {
+	ctx = MaskSecret(c.LogContext(ctx, SubsystemInstaller), password)
+	tflog.SubsystemInfo(ctx, SubsystemInstaller, "Wiping all disks", map[string]interface{}{"server_ip": serverIP})
+	s, err := c.openRescueSession(ctx, serverIP, password)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	disks, err := s.disks(ctx)
+	if err != nil {
+		return err
+	}
+	if err := s.wipe(ctx, disks); err != nil {
+		return err
+	}
+	_, err = s.run(ctx, "sync")
+	return err
+}
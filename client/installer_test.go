@@ -0,0 +1,126 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/client"
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/robotfake"
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/shared"
+)
+
+const rescuePassword = "rescue-secret"
+
+func newRescue(t *testing.T, disks ...string) (*robotfake.RescueSSH, *client.HetznerRobotClient) {
+	t.Helper()
+	rescue, err := robotfake.NewRescueSSH(rescuePassword, disks...)
+	if err != nil {
+		t.Fatalf("NewRescueSSH: %v", err)
+	}
+	t.Cleanup(rescue.Close)
+	c, err := client.NewHetznerRobotClient(&shared.ProviderConfig{RescueSSHPort: rescue.Port()})
+	if err != nil {
+		t.Fatalf("NewHetznerRobotClient: %v", err)
+	}
+	return rescue, c
+}
+
+func TestInstallTalosOSWritesImageToFirstNVMeDisk(t *testing.T) {
+	rescue, c := newRescue(t, "sda", "nvme0n1", "nvme1n1")
+
+	if err := c.Installer().InstallTalos(context.Background(), rescue.Host(), rescuePassword); err != nil {
+		t.Fatalf("InstallTalos: %v", err)
+	}
+
+	disks := rescue.Disks()
+	if disks[0].Wiped || disks[0].Image != "" {
+		t.Errorf("sda = %+v, want untouched", disks[0])
+	}
+	if !strings.Contains(disks[1].Image, "talos") {
+		t.Errorf("nvme0n1 image = %q, want the Talos image", disks[1].Image)
+	}
+	if !disks[2].Wiped || disks[2].Image != "" {
+		t.Errorf("nvme1n1 = %+v, want wiped without image", disks[2])
+	}
+	if rescue.Reboots() != 1 {
+		t.Errorf("rebooted %d times, want 1", rescue.Reboots())
+	}
+	cmds := rescue.Commands()
+	if cmds[len(cmds)-1] != "reboot" {
+		t.Errorf("commands = %q", cmds)
+	}
+}
+
+func TestInstallTalosOSReportsFailedStep(t *testing.T) {
+	rescue, c := newRescue(t)
+	rescue.FailNextCommand("wipefs --all --force /dev/nvme1n1", 1, 1, "wipefs: device busy, password "+rescuePassword+"\n")
+
+	err := c.Installer().InstallTalos(context.Background(), rescue.Host(), rescuePassword)
+	if err == nil {
+		t.Fatal("InstallTalos succeeded, want error")
+	}
+	if !strings.Contains(err.Error(), "wipefs --all --force /dev/nvme1n1") {
+		t.Errorf("error %q does not name the failed command", err)
+	}
+	if strings.Contains(err.Error(), rescuePassword) {
+		t.Errorf("error %q leaks the rescue password", err)
+	}
+	for _, cmd := range rescue.Commands() {
+		if strings.HasPrefix(cmd, "wget") || cmd == "reboot" {
+			t.Errorf("ran %q after the failed step", cmd)
+		}
+	}
+}
+
+func TestInstallTalosOSIgnoresToleratedFailures(t *testing.T) {
+	rescue, c := newRescue(t)
+	rescue.FailNextCommand("mdadm --stop", 1, 1, "mdadm: error opening /dev/md0\n")
+
+	if err := c.Installer().InstallTalos(context.Background(), rescue.Host(), rescuePassword); err != nil {
+		t.Fatalf("InstallTalos: %v", err)
+	}
+}
+
+func TestInstallTalosOSRejectsWrongPassword(t *testing.T) {
+	rescue, c := newRescue(t)
+
+	if err := c.Installer().InstallTalos(context.Background(), rescue.Host(), "wrong"); err == nil {
+		t.Fatal("InstallTalos succeeded with a wrong password")
+	}
+	if len(rescue.Commands()) != 0 {
+		t.Errorf("ran commands without authentication: %q", rescue.Commands())
+	}
+}
+
+func TestWipeAllDisks(t *testing.T) {
+	rescue, c := newRescue(t, "nvme0n1", "nvme1n1")
+
+	if err := c.Installer().WipeDisks(context.Background(), rescue.Host(), rescuePassword); err != nil {
+		t.Fatalf("WipeDisks: %v", err)
+	}
+	for _, d := range rescue.Disks() {
+		if !d.Wiped {
+			t.Errorf("%s not wiped", d.Name)
+		}
+	}
+	if rescue.Reboots() != 0 {
+		t.Errorf("rebooted %d times, want 0", rescue.Reboots())
+	}
+}
+
+func TestWaitForSSHHonorsTimeout(t *testing.T) {
+	rescue, c := newRescue(t)
+	rescue.Close()
+
+	start := time.Now()
+	err := c.Installer().WaitForSSH(context.Background(), rescue.Host(), 50*time.Millisecond, 10*time.Millisecond)
+	if err == nil {
+		t.Fatal("WaitForSSH succeeded against a closed port")
+	}
+	if errors.Is(err, context.DeadlineExceeded) || time.Since(start) > 5*time.Second {
+		t.Errorf("WaitForSSH = %v after %v", err, time.Since(start))
+	}
+}
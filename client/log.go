@@ -0,0 +1,60 @@
+package client
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Log subsystems. Their level can be tuned individually with
+// TF_LOG_PROVIDER_HETZNERROBOT_<SUBSYSTEM>, e.g.
+// TF_LOG_PROVIDER_HETZNERROBOT_CLIENT=TRACE.
+const (
+	SubsystemClient    = "client"
+	SubsystemFirewall  = "firewall"
+	SubsystemVSwitch   = "vswitch"
+	SubsystemInstaller = "installer"
+	SubsystemOrder     = "order"
+)
+
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`"password"\s*:\s*"[^"]*"`),
+	regexp.MustCompile(`(?:^|&)password=[^&]*`),
+}
+
+// LogContext returns ctx with the given log subsystem initialised. Field
+// values named like secrets, the API credentials and anything that looks like
+// a password in a request or response body are masked.
+func (c *HetznerRobotClient) LogContext(ctx context.Context, subsystem string) context.Context {
+	ctx = tflog.NewSubsystem(ctx, subsystem, tflog.WithLevelFromEnv("TF_LOG_PROVIDER_HETZNERROBOT", subsystem))
+	ctx = tflog.SubsystemMaskFieldValuesWithFieldKeys(ctx, subsystem, "password", "rescue_password", "authorization")
+	ctx = tflog.SubsystemMaskLogRegexes(ctx, subsystem, secretPatterns...)
+	if c.Config.Password != "" {
+		ctx = tflog.SubsystemMaskLogStrings(ctx, subsystem, c.Config.Password)
+	}
+	if secrets, _ := ctx.Value(secretsKey{}).([]string); len(secrets) > 0 {
+		ctx = tflog.SubsystemMaskLogStrings(ctx, subsystem, secrets...)
+	}
+	return ctx
+}
+
+type secretsKey struct{}
+
+// MaskSecret masks value in every message and field logged through ctx, for
+// all subsystems including those initialised later by LogContext. It is used
+// for secrets that only become known at runtime, such as rescue system
+// passwords.
+func MaskSecret(ctx context.Context, value string) context.Context {
+	if value == "" {
+		return ctx
+	}
+	secrets, _ := ctx.Value(secretsKey{}).([]string)
+	secrets = append(secrets[:len(secrets):len(secrets)], value)
+	ctx = context.WithValue(ctx, secretsKey{}, secrets)
+	ctx = tflog.MaskLogStrings(ctx, value)
+	for _, subsystem := range []string{SubsystemClient, SubsystemFirewall, SubsystemVSwitch, SubsystemInstaller, SubsystemOrder} {
+		ctx = tflog.SubsystemMaskLogStrings(ctx, subsystem, value)
+	}
+	return ctx
+}
@@ -0,0 +1,63 @@
+package client_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-log/tflogtest"
+
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/client"
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/robotfake"
+)
+
+func TestLogsMaskSecrets(t *testing.T) {
+	t.Setenv("TF_LOG_PROVIDER_HETZNERROBOT_CLIENT", "TRACE")
+	t.Setenv("TF_LOG_PROVIDER_HETZNERROBOT_INSTALLER", "TRACE")
+	fake := robotfake.New(robotfake.Options{})
+	defer fake.Close()
+	fake.AddServer(robotfake.Server{Number: 321, IP: "203.0.113.10"})
+	fake.AddServerProduct(robotfake.ServerProduct{ID: "EX44", Name: "EX44", Locations: []string{"FSN1"}, MonthlyNet: "44.0000"})
+	c := newTestClient(t, fake, 0)
+
+	var output bytes.Buffer
+	ctx := tflogtest.RootLogger(context.Background(), &output)
+
+	const orderPassword = "order-pass-4711"
+	if _, err := c.Ordering().OrderServer(ctx, client.ServerOrder{ProductID: "EX44", Password: orderPassword, Test: true}); err != nil {
+		t.Fatalf("OrderServer: %v", err)
+	}
+	rescue, err := c.Boot().EnableRescue(ctx, 321, "linux", nil)
+	if err != nil {
+		t.Fatalf("EnableRescue: %v", err)
+	}
+	if rescue.Password == "" {
+		t.Fatal("EnableRescue returned no password")
+	}
+	// The installer masks the rescue password once it knows it, in the
+	// subsystem it already logs to and in those initialised afterwards.
+	installerCtx := client.MaskSecret(c.LogContext(ctx, client.SubsystemInstaller), rescue.Password)
+	tflog.SubsystemInfo(installerCtx, client.SubsystemInstaller, "Connecting with "+rescue.Password, map[string]interface{}{
+		"login": "root:" + rescue.Password,
+	})
+	tflog.SubsystemDebug(c.LogContext(installerCtx, client.SubsystemClient), client.SubsystemClient, "Rescue login root:"+rescue.Password)
+
+	logs := output.String()
+	if !strings.Contains(logs, "Sending Robot API request") || !strings.Contains(logs, "Connecting with") {
+		t.Fatalf("logs are missing the expected entries:\n%s", logs)
+	}
+	basicAuth := base64.StdEncoding.EncodeToString([]byte(fake.Username() + ":" + fake.Password()))
+	for name, secret := range map[string]string{
+		"API password":    fake.Password(),
+		"Basic auth":      basicAuth,
+		"order password":  orderPassword,
+		"rescue password": rescue.Password,
+	} {
+		if strings.Contains(logs, secret) {
+			t.Errorf("logs contain the %s %q:\n%s", name, secret, logs)
+		}
+	}
+}
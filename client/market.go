@@ -0,0 +1,99 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// MarketProduct is a used server offered on the server market (auction), as
+// returned by /order/server_market/product. Unless FixedPrice is set its
+// price drops every NextReduce seconds until it is sold.
+type MarketProduct struct {
+	ID           int      `json:"id"`
+	Name         string   `json:"name"`
+	Description  []string `json:"description"`
+	Traffic      string   `json:"traffic"`
+	Dists        []string `json:"dist"`
+	Archs        []int    `json:"arch"`
+	Langs        []string `json:"lang"`
+	CPU          string   `json:"cpu"`
+	CPUBenchmark int      `json:"cpu_benchmark"`
+	// MemorySize is in GB, and so is HDDSize, the size of each of the
+	// HDDCount disks.
+	MemorySize   int    `json:"memory_size"`
+	HDDSize      int    `json:"hdd_size"`
+	HDDText      string `json:"hdd_text"`
+	HDDCount     int    `json:"hdd_count"`
+	Datacenter   string `json:"datacenter"`
+	NetworkSpeed string `json:"network_speed"`
+	// Prices are net and gross (VAT) monthly and setup prices in euros.
+	Price          string `json:"price"`
+	PriceVAT       string `json:"price_vat"`
+	PriceSetup     string `json:"price_setup"`
+	PriceSetupVAT  string `json:"price_setup_vat"`
+	FixedPrice     bool   `json:"fixed_price"`
+	NextReduce     int    `json:"next_reduce"`
+	NextReduceDate string `json:"next_reduce_date"`
+}
+
+// MarketOrder is the form of POST /order/server_market/transaction.
+type MarketOrder struct {
+	ProductID int `form:"product_id"`
+	// AuthorizedKeys are fingerprints of SSH keys stored in Robot. Password
+	// is only used if no keys are given.
+	AuthorizedKeys []string `form:"authorized_key,omitempty"`
+	Password       string   `form:"password,omitempty"`
+	Dist           string   `form:"dist,omitempty"`
+	Arch           int      `form:"arch,omitempty"`
+	Lang           string   `form:"lang,omitempty"`
+	Comment        string   `form:"comment,omitempty"`
+	Addons         []string `form:"addon,omitempty"`
+	// Test validates the order without placing it.
+	Test bool `form:"test"`
+}
+
+func (c orderingService) MarketProducts(ctx context.Context) ([]MarketProduct, error) {
+	resp, err := c.DoRequest(ctx, "GET", "/order/server_market/product", nil, "")
+	if err != nil {
+		return nil, fmt.Errorf("error fetching server market products: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error fetching server market products: %w", newRobotError(resp))
+	}
+	var result []struct {
+		Product MarketProduct `json:"product"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("error parsing server market products: %w", err)
+	}
+	products := make([]MarketProduct, 0, len(result))
+	for _, p := range result {
+		products = append(products, p.Product)
+	}
+	return products, nil
+}
+
+func (c orderingService) OrderMarketServer(ctx context.Context, order MarketOrder) (*ServerTransaction, error) {
+	resp, err := c.doForm(ctx, "POST", "/order/server_market/transaction", order)
+	if err != nil {
+		return nil, fmt.Errorf("error ordering server market product %d: %w", order.ProductID, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return nil, fmt.Errorf("error ordering server market product %d: %w", order.ProductID, newRobotError(resp))
+	}
+	return decodeServerTransaction(resp)
+}
+
+func (c orderingService) MarketTransaction(ctx context.Context, id string) (*ServerTransaction, error) {
+	return c.getTransaction(ctx, "/order/server_market/transaction/"+url.PathEscape(id), id)
+}
+
+func (c orderingService) WaitMarketTransaction(ctx context.Context, id string, timeout time.Duration) (*ServerTransaction, error) {
+	return c.waitServerTransaction(ctx, id, timeout, c.MarketTransaction)
+}
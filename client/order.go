@@ -0,0 +1,234 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Price is an amount in euros. Robot sends amounts as decimal strings, e.g.
+// "39.0000", which are kept as they are.
+type Price struct {
+	Net   string `json:"net"`
+	Gross string `json:"gross"`
+}
+
+// LocationPrice is the price of a product in one location.
+type LocationPrice struct {
+	Location string `json:"location"`
+	// Price is charged monthly, PriceSetup once.
+	Price      Price `json:"price"`
+	PriceSetup Price `json:"price_setup"`
+}
+
+// OrderableAddon is an addon that can be ordered together with a product.
+type OrderableAddon struct {
+	ID     string          `json:"id"`
+	Name   string          `json:"name"`
+	Min    int             `json:"min"`
+	Max    int             `json:"max"`
+	Prices []LocationPrice `json:"prices"`
+}
+
+// ServerProduct is a product of the standard server catalog, as returned by
+// /order/server/product.
+type ServerProduct struct {
+	ID              string           `json:"id"`
+	Name            string           `json:"name"`
+	Description     []string         `json:"description"`
+	Traffic         string           `json:"traffic"`
+	Dists           []string         `json:"dist"`
+	Archs           []int            `json:"arch"`
+	Langs           []string         `json:"lang"`
+	Locations       []string         `json:"location"`
+	Prices          []LocationPrice  `json:"prices"`
+	OrderableAddons []OrderableAddon `json:"orderable_addons"`
+}
+
+// ServerOrder is the form of POST /order/server/transaction.
+type ServerOrder struct {
+	ProductID string `form:"product_id"`
+	// AuthorizedKeys are fingerprints of SSH keys stored in Robot. Password
+	// is only used if no keys are given.
+	AuthorizedKeys []string `form:"authorized_key,omitempty"`
+	Password       string   `form:"password,omitempty"`
+	Location       string   `form:"location,omitempty"`
+	Dist           string   `form:"dist,omitempty"`
+	Arch           int      `form:"arch,omitempty"`
+	Lang           string   `form:"lang,omitempty"`
+	Comment        string   `form:"comment,omitempty"`
+	Addons         []string `form:"addon,omitempty"`
+	// Test validates the order without placing it.
+	Test bool `form:"test"`
+}
+
+// TransactionProduct is the product of a transaction, with the options that
+// were chosen for it.
+type TransactionProduct struct {
+	// ID is a name such as "AX41-NVMe" for the standard catalog and the
+	// decimal product number for the server market.
+	ID          string   `json:"-"`
+	Name        string   `json:"name"`
+	Description []string `json:"description"`
+	Traffic     string   `json:"traffic"`
+	Dist        string   `json:"dist"`
+	Lang        string   `json:"lang"`
+	Location    string   `json:"location"`
+	// Datacenter is only set for server market products.
+	Datacenter string `json:"datacenter"`
+}
+
+func (p *TransactionProduct) UnmarshalJSON(data []byte) error {
+	type plain TransactionProduct
+	var raw struct {
+		plain
+		ID json.RawMessage `json:"id"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*p = TransactionProduct(raw.plain)
+	if len(raw.ID) > 0 && raw.ID[0] == '"' {
+		return json.Unmarshal(raw.ID, &p.ID)
+	}
+	if len(raw.ID) > 0 && string(raw.ID) != "null" {
+		p.ID = string(raw.ID)
+	}
+	return nil
+}
+
+// ServerTransaction is an order of a server, from the standard catalog or the
+// server market. Robot assigns the server number
+// and IP once the server has been provisioned; until then they are empty.
+type ServerTransaction struct {
+	ID           string             `json:"id"`
+	Date         string             `json:"date"`
+	Status       string             `json:"status"`
+	ServerNumber int                `json:"server_number"`
+	ServerIP     string             `json:"server_ip"`
+	Comment      string             `json:"comment"`
+	Product      TransactionProduct `json:"product"`
+	Addons       []string           `json:"addons"`
+}
+
+func (c orderingService) ServerProducts(ctx context.Context) ([]ServerProduct, error) {
+	resp, err := c.DoRequest(ctx, "GET", "/order/server/product", nil, "")
+	if err != nil {
+		return nil, fmt.Errorf("error fetching server products: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error fetching server products: %w", newRobotError(resp))
+	}
+	var result []struct {
+		Product ServerProduct `json:"product"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("error parsing server products: %w", err)
+	}
+	products := make([]ServerProduct, 0, len(result))
+	for _, p := range result {
+		products = append(products, p.Product)
+	}
+	return products, nil
+}
+
+func (c orderingService) OrderServer(ctx context.Context, order ServerOrder) (*ServerTransaction, error) {
+	resp, err := c.doForm(ctx, "POST", "/order/server/transaction", order)
+	if err != nil {
+		return nil, fmt.Errorf("error ordering server %s: %w", order.ProductID, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return nil, fmt.Errorf("error ordering server %s: %w", order.ProductID, newRobotError(resp))
+	}
+	return decodeServerTransaction(resp)
+}
+
+func (c orderingService) ServerTransaction(ctx context.Context, id string) (*ServerTransaction, error) {
+	return c.getTransaction(ctx, "/order/server/transaction/"+url.PathEscape(id), id)
+}
+
+func (c orderingService) getTransaction(ctx context.Context, path, id string) (*ServerTransaction, error) {
+	resp, err := c.DoRequest(ctx, "GET", path, nil, "")
+	if err != nil {
+		return nil, fmt.Errorf("error fetching transaction %s: %w", id, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error fetching transaction %s: %w", id, newRobotError(resp))
+	}
+	return decodeServerTransaction(resp)
+}
+
+func (c orderingService) WaitServerTransaction(ctx context.Context, id string, timeout time.Duration) (*ServerTransaction, error) {
+	return c.waitServerTransaction(ctx, id, timeout, c.ServerTransaction)
+}
+
+// waitServerTransaction polls a server or server market transaction with
+// get until its server has been provisioned.
+func (c orderingService) waitServerTransaction(ctx context.Context, id string, timeout time.Duration, get func(context.Context, string) (*ServerTransaction, error)) (*ServerTransaction, error) {
+	var tx *ServerTransaction
+	err := c.waitTransaction(ctx, id, timeout, func(ctx context.Context) (bool, string, error) {
+		var err error
+		tx, err = get(ctx, id)
+		if err != nil {
+			return false, "", err
+		}
+		return tx.Status == "ready" && tx.ServerNumber != 0, tx.Status, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// waitTransaction polls an order transaction with check, which reports the
+// transaction status. A "cancelled" transaction fails with
+// ErrOperationFailed.
+func (c orderingService) waitTransaction(ctx context.Context, id string, timeout time.Duration, check PollFunc) error {
+	ctx = c.LogContext(ctx, SubsystemOrder)
+	poller := c.newPoller(fmt.Sprintf("transaction %s to complete", id), func(p PollProgress) {
+		tflog.SubsystemDebug(ctx, SubsystemOrder, "Order is still in process", map[string]interface{}{
+			"transaction_id": id,
+			"status":         p.Status,
+			"attempt":        p.Attempt,
+			"retry_in":       p.Next.String(),
+		})
+	})
+	poller.Timeout = timeout
+	err := poller.Poll(ctx, func(ctx context.Context) (bool, string, error) {
+		done, status, err := check(ctx)
+		switch {
+		case err != nil:
+			return false, "", err
+		case status == "cancelled":
+			return false, status, fmt.Errorf("%w: transaction %s was cancelled", ErrOperationFailed, id)
+		case done:
+			tflog.SubsystemDebug(ctx, SubsystemOrder, "Order completed", map[string]interface{}{"transaction_id": id})
+		}
+		return done, status, nil
+	})
+	if err != nil {
+		return err
+	}
+	// A new server, IP or subnet is not in a listing cached before it was
+	// provisioned.
+	c.servers.invalidate()
+	return nil
+}
+
+func decodeServerTransaction(resp *http.Response) (*ServerTransaction, error) {
+	var result struct {
+		Transaction ServerTransaction `json:"transaction"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("error parsing transaction response: %w", err)
+	}
+	return &result.Transaction, nil
+}
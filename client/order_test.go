@@ -0,0 +1,159 @@
+package client_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/client"
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/robotfake"
+)
+
+func TestOrderServerWaitsForProvisioning(t *testing.T) {
+	fake := robotfake.New(robotfake.Options{PendingPolls: 2})
+	defer fake.Close()
+	fake.AddServer(robotfake.Server{Number: 321, IP: "203.0.113.10"})
+	fake.AddServerProduct(robotfake.ServerProduct{ID: "AX41-NVMe", Name: "AX41-NVMe", Dists: []string{"Rescue system"}, Locations: []string{"FSN1", "HEL1"}, MonthlyNet: "39.0000"})
+	c := newTestClient(t, fake, 0)
+	ctx := context.Background()
+
+	// Fill the server cache before the new server exists.
+	if _, err := c.Servers().List(ctx); err != nil {
+		t.Fatalf("Servers().List: %v", err)
+	}
+
+	tx, err := c.Ordering().OrderServer(ctx, client.ServerOrder{
+		ProductID:      "AX41-NVMe",
+		Location:       "HEL1",
+		AuthorizedKeys: []string{"15:28:b0:03:95:f0:77:b3:10:56:15:6b:77:22:a5:bb"},
+	})
+	if err != nil {
+		t.Fatalf("OrderServer: %v", err)
+	}
+	if tx.Status != "in process" || tx.ServerNumber != 0 {
+		t.Errorf("new transaction = %+v, want in process without server", tx)
+	}
+	if got := fake.ServerTransactions()[0].AuthorizedKeys; len(got) != 1 {
+		t.Errorf("authorized keys sent = %v", got)
+	}
+
+	tx, err = c.Ordering().WaitServerTransaction(ctx, tx.ID, 0)
+	if err != nil {
+		t.Fatalf("WaitServerTransaction: %v", err)
+	}
+	if tx.Status != "ready" || tx.ServerNumber == 0 || tx.ServerIP == "" || tx.Product.Location != "HEL1" {
+		t.Fatalf("completed transaction = %+v", tx)
+	}
+	srv, err := c.Servers().Get(ctx, tx.ServerNumber)
+	if err != nil {
+		t.Fatalf("Servers().Get(%d) after order: %v", tx.ServerNumber, err)
+	}
+	if srv.IP != tx.ServerIP {
+		t.Errorf("server IP = %q, want %q", srv.IP, tx.ServerIP)
+	}
+}
+
+func TestOrderServerCancelledAndTest(t *testing.T) {
+	fake := robotfake.New(robotfake.Options{PendingPolls: 1})
+	defer fake.Close()
+	fake.AddServerProduct(robotfake.ServerProduct{ID: "EX44", Name: "EX44", Locations: []string{"FSN1"}, MonthlyNet: "44.0000"})
+	c := newTestClient(t, fake, 0)
+	ctx := context.Background()
+
+	tx, err := c.Ordering().OrderServer(ctx, client.ServerOrder{ProductID: "EX44", Password: "secret"})
+	if err != nil {
+		t.Fatalf("OrderServer: %v", err)
+	}
+	fake.CancelServerTransaction(tx.ID)
+	if _, err := c.Ordering().WaitServerTransaction(ctx, tx.ID, 0); !client.IsOperationFailed(err) {
+		t.Errorf("WaitServerTransaction on cancelled order: err = %v, want operation failed", err)
+	}
+
+	tx, err = c.Ordering().OrderServer(ctx, client.ServerOrder{ProductID: "EX44", Test: true})
+	if err != nil {
+		t.Fatalf("test OrderServer: %v", err)
+	}
+	if _, err := c.Ordering().ServerTransaction(ctx, tx.ID); !client.IsNotFound(err) {
+		t.Errorf("ServerTransaction of test order: err = %v, want not found", err)
+	}
+
+	if _, err := c.Ordering().OrderServer(ctx, client.ServerOrder{ProductID: "EX44", Location: "NBG1", Test: true}); err == nil {
+		t.Error("OrderServer in a location the product is not offered in succeeded")
+	}
+}
+
+func TestOrderMarketServer(t *testing.T) {
+	fake := robotfake.New(robotfake.Options{PendingPolls: 1})
+	defer fake.Close()
+	fake.AddMarketProduct(robotfake.MarketProduct{ID: 283693, Name: "SB36", CPU: "Intel Core i7-6700", MemorySize: 64, HDDCount: 2, HDDSize: 512, Datacenter: "HEL1-DC2", Price: "31.0000"})
+	c := newTestClient(t, fake, 0)
+	ctx := context.Background()
+
+	products, err := c.Ordering().MarketProducts(ctx)
+	if err != nil {
+		t.Fatalf("MarketProducts: %v", err)
+	}
+	if len(products) != 1 || products[0].ID != 283693 || products[0].MemorySize != 64 || products[0].PriceVAT != "36.8900" {
+		t.Fatalf("market products = %+v", products)
+	}
+
+	tx, err := c.Ordering().OrderMarketServer(ctx, client.MarketOrder{ProductID: 283693})
+	if err != nil {
+		t.Fatalf("OrderMarketServer: %v", err)
+	}
+	if tx.Product.ID != "283693" || tx.Product.Datacenter != "HEL1-DC2" {
+		t.Errorf("transaction product = %+v", tx.Product)
+	}
+	// Market transactions are not visible as standard ones.
+	if _, err := c.Ordering().ServerTransaction(ctx, tx.ID); !client.IsNotFound(err) {
+		t.Errorf("ServerTransaction of market order: err = %v, want not found", err)
+	}
+	tx, err = c.Ordering().WaitMarketTransaction(ctx, tx.ID, 0)
+	if err != nil {
+		t.Fatalf("WaitMarketTransaction: %v", err)
+	}
+	srv, ok := fake.Server(tx.ServerNumber)
+	if !ok || srv.Datacenter != "HEL1-DC2" || srv.Product != "SB36" {
+		t.Errorf("ordered server = %+v, %v", srv, ok)
+	}
+	if products, err := c.Ordering().MarketProducts(ctx); !client.IsNotFound(err) {
+		t.Errorf("market after the only product was sold = %v, %v", products, err)
+	}
+}
+
+func TestOrderAddon(t *testing.T) {
+	fake := robotfake.New(robotfake.Options{PendingPolls: 1})
+	defer fake.Close()
+	fake.AddServer(robotfake.Server{Number: 321, IP: "203.0.113.10", Datacenter: "FSN1-DC14"})
+	c := newTestClient(t, fake, 0)
+	ctx := context.Background()
+
+	addons, err := c.Ordering().AddonProducts(ctx, 321)
+	if err != nil {
+		t.Fatalf("AddonProducts: %v", err)
+	}
+	if len(addons) == 0 || addons[0].ID != "additional_ipv4" || addons[0].Price.Location != "FSN1" {
+		t.Fatalf("addons = %+v", addons)
+	}
+
+	if _, err := c.Ordering().OrderAddon(ctx, client.AddonOrder{ServerNumber: 321, ProductID: "additional_ipv4"}); err == nil {
+		t.Error("OrderAddon of an IPv4 address without reason succeeded")
+	}
+	tx, err := c.Ordering().OrderAddon(ctx, client.AddonOrder{ServerNumber: 321, ProductID: "additional_ipv4", Reason: "Failover for the ingress"})
+	if err != nil {
+		t.Fatalf("OrderAddon: %v", err)
+	}
+	tx, err = c.Ordering().WaitAddonTransaction(ctx, tx.ID, 0)
+	if err != nil {
+		t.Fatalf("WaitAddonTransaction: %v", err)
+	}
+	if len(tx.Resources) != 1 || tx.Resources[0].Type != "ip" {
+		t.Fatalf("resources = %+v", tx.Resources)
+	}
+	srv, err := c.Servers().Get(ctx, 321)
+	if err != nil {
+		t.Fatalf("Servers().Get: %v", err)
+	}
+	if len(srv.IPs) != 2 || srv.IPs[1] != tx.Resources[0].ID {
+		t.Errorf("server IPs = %v, want the ordered %s added", srv.IPs, tx.Resources[0].ID)
+	}
+}
@@ -0,0 +1,132 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	defaultPollMinInterval = 2 * time.Second
+	defaultPollMaxInterval = 30 * time.Second
+	defaultPollMultiplier  = 2
+)
+
+// ErrOperationFailed is returned by a poll whose operation ended in a state
+// Robot reports as failed, e.g. a vSwitch server assignment in "failed".
+var ErrOperationFailed = errors.New("operation failed")
+
+// IsOperationFailed reports whether err was caused by an asynchronous Robot
+// operation that failed.
+func IsOperationFailed(err error) bool {
+	return errors.Is(err, ErrOperationFailed)
+}
+
+// PollFunc checks the state of an asynchronous operation once. It returns
+// done when the operation has completed, and a short description of the
+// current state for progress reports. Any error ends the poll: the request
+// layer already retries transient failures, so an error here is terminal.
+// Wrap ErrOperationFailed for operations that Robot reports as failed.
+type PollFunc func(ctx context.Context) (done bool, status string, err error)
+
+// PollProgress is passed to Poller.OnProgress after every check that did
+// not complete the operation.
+type PollProgress struct {
+	Attempt int
+	Status  string
+	Elapsed time.Duration
+	// Next is the pause before the next check.
+	Next time.Duration
+}
+
+// Poller waits for an asynchronous Robot operation. The first check is
+// made immediately; after that the pause between checks starts at
+// MinInterval and grows by Multiplier up to MaxInterval.
+type Poller struct {
+	// Description names what is awaited in errors, e.g. "firewall of
+	// 203.0.113.10 to be applied".
+	Description string
+
+	MinInterval time.Duration
+	MaxInterval time.Duration
+	Multiplier  float64
+
+	// Timeout bounds the poll in addition to the deadline of the context.
+	// Zero means the context alone decides.
+	Timeout time.Duration
+
+	OnProgress func(PollProgress)
+}
+
+// newPoller returns a Poller with the intervals of the provider
+// configuration.
+func (c *HetznerRobotClient) newPoller(description string, onProgress func(PollProgress)) Poller {
+	return Poller{
+		Description: description,
+		MinInterval: c.Config.PollMinInterval,
+		MaxInterval: c.Config.PollMaxInterval,
+		OnProgress:  onProgress,
+	}
+}
+
+// Poll calls check until it reports done or fails, or the deadline passes.
+// On timeout the error wraps context.DeadlineExceeded and names the last
+// status seen.
+func (p Poller) Poll(ctx context.Context, check PollFunc) error {
+	if p.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.Timeout)
+		defer cancel()
+	}
+	interval := p.MinInterval
+	if interval <= 0 {
+		interval = defaultPollMinInterval
+	}
+	maxInterval := p.MaxInterval
+	if maxInterval < interval {
+		maxInterval = interval
+	}
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = defaultPollMultiplier
+	}
+
+	start := time.Now()
+	var status string
+	for attempt := 1; ; attempt++ {
+		done, s, err := check(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return p.timeoutError(ctx, status)
+			}
+			return err
+		}
+		status = s
+		if done {
+			return nil
+		}
+		if p.OnProgress != nil {
+			p.OnProgress(PollProgress{
+				Attempt: attempt,
+				Status:  status,
+				Elapsed: time.Since(start),
+				Next:    interval,
+			})
+		}
+		if err := sleepContext(ctx, interval); err != nil {
+			return p.timeoutError(ctx, status)
+		}
+		interval = time.Duration(float64(interval) * multiplier)
+		if interval > maxInterval {
+			interval = maxInterval
+		}
+	}
+}
+
+func (p Poller) timeoutError(ctx context.Context, status string) error {
+	if status == "" {
+		return fmt.Errorf("gave up waiting for %s: %w", p.Description, ctx.Err())
+	}
+	return fmt.Errorf("gave up waiting for %s, last status %q: %w", p.Description, status, ctx.Err())
+}
@@ -0,0 +1,99 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/client"
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/robotfake"
+)
+
+func TestPollerBacksOffUpToMaxInterval(t *testing.T) {
+	var pauses []time.Duration
+	p := client.Poller{
+		Description: "test",
+		MinInterval: time.Millisecond,
+		MaxInterval: 4 * time.Millisecond,
+		OnProgress:  func(p client.PollProgress) { pauses = append(pauses, p.Next) },
+	}
+	checks := 0
+	err := p.Poll(context.Background(), func(context.Context) (bool, string, error) {
+		checks++
+		return checks == 5, "pending", nil
+	})
+	if err != nil {
+		t.Fatalf("Poll: %v", err)
+	}
+	want := []time.Duration{time.Millisecond, 2 * time.Millisecond, 4 * time.Millisecond, 4 * time.Millisecond}
+	if fmt.Sprint(pauses) != fmt.Sprint(want) {
+		t.Errorf("pauses = %v, want %v", pauses, want)
+	}
+}
+
+func TestPollerStopsAtTerminalFailure(t *testing.T) {
+	p := client.Poller{Description: "test", MinInterval: time.Millisecond}
+	checks := 0
+	err := p.Poll(context.Background(), func(context.Context) (bool, string, error) {
+		checks++
+		return false, "failed", fmt.Errorf("%w: broken", client.ErrOperationFailed)
+	})
+	if !client.IsOperationFailed(err) {
+		t.Errorf("err = %v, want operation failed", err)
+	}
+	if checks != 1 {
+		t.Errorf("checks = %d, want 1", checks)
+	}
+}
+
+func TestPollerTimeoutNamesLastStatus(t *testing.T) {
+	p := client.Poller{Description: "test", MinInterval: time.Millisecond, Timeout: 20 * time.Millisecond}
+	err := p.Poll(context.Background(), func(context.Context) (bool, string, error) {
+		return false, "in process", nil
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want deadline exceeded", err)
+	}
+	if !strings.Contains(err.Error(), `"in process"`) {
+		t.Errorf("err = %v, want the last status", err)
+	}
+}
+
+func TestFirewallSetWaitsUntilDisabled(t *testing.T) {
+	fake := robotfake.New(robotfake.Options{PendingPolls: 2})
+	defer fake.Close()
+	fake.AddServer(robotfake.Server{Number: 321, IP: "203.0.113.10"})
+	c := newTestClient(t, fake, 0)
+
+	err := c.Firewalls().Set(context.Background(), client.HetznerRobotFirewall{IP: "203.0.113.10", Status: "disabled"}, time.Second)
+	if err != nil {
+		t.Fatalf("Firewalls().Set: %v", err)
+	}
+	if fw, _ := fake.Firewall(321); fw.Status != "disabled" {
+		t.Errorf("status = %q, want disabled", fw.Status)
+	}
+}
+
+func TestVSwitchWaitReadyReportsFailedServer(t *testing.T) {
+	fake := robotfake.New(robotfake.Options{PendingPolls: 1})
+	defer fake.Close()
+	fake.AddServer(robotfake.Server{Number: 321, IP: "203.0.113.10"})
+	c := newTestClient(t, fake, 0)
+	ctx := context.Background()
+
+	vsw, err := c.VSwitches().Create(ctx, "test", 4001)
+	if err != nil {
+		t.Fatalf("VSwitches().Create: %v", err)
+	}
+	if err := c.VSwitches().AddServers(ctx, vsw.ID, []client.VSwitchServer{{ServerNumber: 321}}); err != nil {
+		t.Fatalf("VSwitches().AddServers: %v", err)
+	}
+	fake.FailVSwitchServer(vsw.ID, 321)
+
+	if err := c.VSwitches().WaitReady(ctx, vsw.ID, time.Second); !client.IsOperationFailed(err) {
+		t.Errorf("WaitReady: err = %v, want operation failed", err)
+	}
+}
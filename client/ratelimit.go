@@ -0,0 +1,102 @@
+package client
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"golang.org/x/time/rate"
+
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/shared"
+)
+
+// DefaultRateLimits are the request budgets applied per endpoint family,
+// slightly below the hourly quotas enforced by Robot. The "default" entry
+// applies to every family without an entry of its own.
+var DefaultRateLimits = map[string]shared.RateLimit{
+	"server":   {RequestsPerHour: 200, Burst: 50},
+	"reset":    {RequestsPerHour: 50, Burst: 10},
+	"boot":     {RequestsPerHour: 500, Burst: 50},
+	"firewall": {RequestsPerHour: 500, Burst: 50},
+	"vswitch":  {RequestsPerHour: 500, Burst: 50},
+	"order":    {RequestsPerHour: 500, Burst: 50},
+	"default":  {RequestsPerHour: 3600, Burst: 100},
+}
+
+// rateLimiter is a set of token buckets, one per endpoint family, shared by
+// every request made through the client.
+type rateLimiter struct {
+	mu      sync.Mutex
+	limits  map[string]shared.RateLimit
+	buckets map[string]*rate.Limiter
+}
+
+func newRateLimiter(overrides map[string]shared.RateLimit) *rateLimiter {
+	limits := make(map[string]shared.RateLimit, len(DefaultRateLimits)+len(overrides))
+	for family, limit := range DefaultRateLimits {
+		limits[family] = limit
+	}
+	for family, limit := range overrides {
+		limits[family] = limit
+	}
+	return &rateLimiter{
+		limits:  limits,
+		buckets: make(map[string]*rate.Limiter),
+	}
+}
+
+func (l *rateLimiter) bucket(family string) *rate.Limiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if b, ok := l.buckets[family]; ok {
+		return b
+	}
+	limit, ok := l.limits[family]
+	if !ok {
+		limit = l.limits["default"]
+	}
+	var b *rate.Limiter
+	if limit.RequestsPerHour <= 0 {
+		b = rate.NewLimiter(rate.Inf, 0)
+	} else {
+		burst := limit.Burst
+		if burst <= 0 {
+			burst = 1
+		}
+		b = rate.NewLimiter(rate.Limit(float64(limit.RequestsPerHour)/time.Hour.Seconds()), burst)
+	}
+	l.buckets[family] = b
+	return b
+}
+
+// Wait blocks until the budget of the endpoint family of path allows another
+// request, or ctx is done.
+func (l *rateLimiter) Wait(ctx context.Context, path string) error {
+	family := endpointFamily(path)
+	r := l.bucket(family).Reserve()
+	delay := r.Delay()
+	if delay == 0 {
+		return nil
+	}
+	tflog.SubsystemDebug(ctx, SubsystemClient, "Throttling Robot API request", map[string]interface{}{
+		"endpoint_family": family,
+		"wait":            delay.String(),
+	})
+	if err := sleepContext(ctx, delay); err != nil {
+		r.Cancel()
+		return err
+	}
+	return nil
+}
+
+// endpointFamily returns the first segment of path, e.g. "server" for
+// "/server/123/cancellation".
+func endpointFamily(path string) string {
+	path = strings.TrimPrefix(path, "/")
+	if i := strings.IndexAny(path, "/?"); i >= 0 {
+		path = path[:i]
+	}
+	return path
+}
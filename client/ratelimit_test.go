@@ -0,0 +1,111 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/client"
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/robotfake"
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/shared"
+)
+
+func withRateLimits(limits map[string]shared.RateLimit) func(*shared.ProviderConfig) {
+	return func(c *shared.ProviderConfig) {
+		c.RateLimits = limits
+	}
+}
+
+func TestRateLimitsThrottleEachEndpointFamily(t *testing.T) {
+	fake := robotfake.New(robotfake.Options{})
+	defer fake.Close()
+	fake.AddServer(robotfake.Server{Number: 321, IP: "203.0.113.10"})
+	// Ten requests per second after a burst of one.
+	c := fake.NewClient(t, withRateLimits(map[string]shared.RateLimit{
+		"server": {RequestsPerHour: 36000, Burst: 1},
+	}))
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if _, err := c.Servers().Fetch(ctx, 321); err != nil {
+			t.Fatalf("Servers().Fetch: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 250*time.Millisecond {
+		t.Errorf("4 server requests took %v, want them spread over about 300ms", elapsed)
+	}
+
+	// Other families have budgets of their own.
+	start = time.Now()
+	for i := 0; i < 4; i++ {
+		if _, err := c.Firewalls().Get(ctx, "203.0.113.10"); err != nil {
+			t.Fatalf("Firewalls().Get: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 200*time.Millisecond {
+		t.Errorf("4 firewall requests took %v, want them sent at once", elapsed)
+	}
+}
+
+func TestRateLimitsWaitEndsWithContext(t *testing.T) {
+	fake := robotfake.New(robotfake.Options{})
+	defer fake.Close()
+	fake.AddServer(robotfake.Server{Number: 321, IP: "203.0.113.10"})
+	c := fake.NewClient(t, withRateLimits(map[string]shared.RateLimit{
+		"server": {RequestsPerHour: 1, Burst: 1},
+	}))
+
+	if _, err := c.Servers().Fetch(context.Background(), 321); err != nil {
+		t.Fatalf("Servers().Fetch: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := c.Servers().Fetch(ctx, 321)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("throttled request returned after %v, want it to stop at the deadline", elapsed)
+	}
+	if got := countRequests(fake, "GET", "/server/321"); got != 1 {
+		t.Errorf("sent %d requests, want 1", got)
+	}
+}
+
+func TestRateLimitsKeepBurstsWithinRobotQuota(t *testing.T) {
+	for _, test := range []struct {
+		name        string
+		limits      map[string]shared.RateLimit
+		rateLimited int
+	}{
+		{name: "unthrottled", limits: map[string]shared.RateLimit{"server": {}}, rateLimited: 2},
+		{name: "throttled", limits: map[string]shared.RateLimit{"server": {RequestsPerHour: 1, Burst: 3}}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			fake := robotfake.New(robotfake.Options{})
+			defer fake.Close()
+			fake.AddServer(robotfake.Server{Number: 321, IP: "203.0.113.10"})
+			fake.SetRateLimit("server", 3)
+			c := fake.NewClient(t, withRateLimits(test.limits))
+
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			rateLimited := 0
+			for i := 0; i < 5; i++ {
+				_, err := c.Servers().Fetch(ctx, 321)
+				switch {
+				case client.IsRateLimited(err):
+					rateLimited++
+				case err != nil && !errors.Is(err, context.DeadlineExceeded):
+					t.Fatalf("Servers().Fetch: %v", err)
+				}
+			}
+			if rateLimited != test.rateLimited {
+				t.Errorf("%d requests rejected with RATE_LIMIT_EXCEEDED, want %d", rateLimited, test.rateLimited)
+			}
+		})
+	}
+}
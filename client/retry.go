@@ -0,0 +1,93 @@
+package client
+
+import (
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// checkRetry decides whether a request should be sent again and how long to
+// wait before doing so. Rate-limit and maintenance responses are retried for
+// every method because Robot rejects them before doing any work; transport
+// errors and other 5xx responses are only retried for idempotent methods.
+// Orders are the exception: a 429 or 503 may come from a proxy after Robot
+// accepted the order, so they are only retried if Robot's
+// RATE_LIMIT_EXCEEDED body proves the order was rejected.
+func (c *HetznerRobotClient) checkRetry(method, path string, attempt int, resp *http.Response, err error) (bool, time.Duration) {
+	if err != nil {
+		return isIdempotent(method), c.backoff(attempt)
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests,
+		resp.StatusCode == http.StatusServiceUnavailable:
+		if isOrder(method, path) && !isRateLimitResponse(resp) {
+			return false, 0
+		}
+		return true, c.retryAfter(resp, attempt)
+	case resp.StatusCode == http.StatusForbidden:
+		if isRateLimitResponse(resp) {
+			return true, c.retryAfter(resp, attempt)
+		}
+		return false, 0
+	case resp.StatusCode >= 500:
+		return isIdempotent(method), c.retryAfter(resp, attempt)
+	}
+	return false, 0
+}
+
+// backoff returns an exponentially growing wait with jitter, bounded by the
+// configured minimum and maximum.
+func (c *HetznerRobotClient) backoff(attempt int) time.Duration {
+	waitMin := c.Config.RetryWaitMin
+	waitMax := c.Config.RetryWaitMax
+	wait := float64(waitMin) * math.Pow(2, float64(attempt))
+	if wait > float64(waitMax) || math.IsInf(wait, 0) {
+		wait = float64(waitMax)
+	}
+	jittered := time.Duration(wait/2 + rand.Float64()*wait/2)
+	if jittered < waitMin {
+		jittered = waitMin
+	}
+	return jittered
+}
+
+func (c *HetznerRobotClient) retryAfter(resp *http.Response, attempt int) time.Duration {
+	header := resp.Header.Get("Retry-After")
+	if header == "" {
+		return c.backoff(attempt)
+	}
+	if seconds, err := strconv.Atoi(header); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(header); err == nil {
+		if wait := time.Until(date); wait > 0 {
+			return wait
+		}
+		return 0
+	}
+	return c.backoff(attempt)
+}
+
+// isRateLimitResponse reports whether a 403 response carries Robot's
+// RATE_LIMIT_EXCEEDED error code. The body is restored so callers can still
+// read it.
+func isRateLimitResponse(resp *http.Response) bool {
+	return IsRateLimited(parseRobotError(resp.StatusCode, []byte(peekBody(resp))))
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// isOrder reports whether a request places an order, which must not be sent
+// twice.
+func isOrder(method, path string) bool {
+	return method == http.MethodPost && endpointFamily(path) == "order"
+}
@@ -1,30 +1,34 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"sort"
-	"sync"
 )
 
-func (c *HetznerRobotClient) FetchAllServers() ([]Server, error) {
+// fetchAll reads the server listing directly from Robot.
+func (c serverService) fetchAll(ctx context.Context) ([]Server, error) {
 	path := "/server"
-	resp, err := c.DoRequest("GET", path, nil, "")
+	resp, err := c.DoRequest(ctx, "GET", path, nil, "")
 	if err != nil {
-		return nil, fmt.Errorf("FetchAllServers request error: %w", err)
+		return nil, fmt.Errorf("error listing servers: %w", err)
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		// Robot answers SERVER_NOT_FOUND when the account has no servers.
+		return []Server{}, nil
+	}
 	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("FetchAllServers: status %d, body %s", resp.StatusCode, string(bodyBytes))
+		return nil, fmt.Errorf("error listing servers: %w", newRobotError(resp))
 	}
 	var raw []struct {
 		Server Server `json:"server"`
 	}
 	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
-		return nil, fmt.Errorf("FetchAllServers decode error: %w", err)
+		return nil, fmt.Errorf("error parsing server list: %w", err)
 	}
 	servers := make([]Server, len(raw))
 	for i, item := range raw {
@@ -33,58 +37,69 @@ func (c *HetznerRobotClient) FetchAllServers() ([]Server, error) {
 	return servers, nil
 }
 
-func (c *HetznerRobotClient) FetchServerByID(id int) (Server, error) {
-	path := fmt.Sprintf("/server/%d", id)
-	resp, err := c.DoRequest("GET", path, nil, "")
+func (c serverService) Fetch(ctx context.Context, number int) (*Server, error) {
+	path := fmt.Sprintf("/server/%d", number)
+	resp, err := c.DoRequest(ctx, "GET", path, nil, "")
 	if err != nil {
-		return Server{}, fmt.Errorf("FetchServerByID request error: %w", err)
+		return nil, fmt.Errorf("error fetching server %d: %w", number, err)
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return Server{}, fmt.Errorf("FetchServerByID %d: status %d, body %s", id, resp.StatusCode, string(bodyBytes))
+		return nil, fmt.Errorf("error fetching server %d: %w", number, newRobotError(resp))
 	}
 	var result struct {
 		Server Server `json:"server"`
 	}
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return Server{}, fmt.Errorf("FetchServerByID decode error: %w", err)
+		return nil, fmt.Errorf("error parsing server %d: %w", number, err)
 	}
-	return result.Server, nil
+	return &result.Server, nil
 }
 
-func (c *HetznerRobotClient) FetchServersByIDs(ids []int) ([]Server, error) {
+func (c serverService) GetMany(ctx context.Context, numbers []int) ([]Server, error) {
 	var (
 		servers []Server
-		mu      sync.Mutex
-		wg      sync.WaitGroup
 		errs    []error
 	)
-	sem := make(chan struct{}, 10)
-	for _, id := range ids {
-		wg.Add(1)
-		go func(serverID int) {
-			defer wg.Done()
-			sem <- struct{}{}
-			srv, err := c.FetchServerByID(serverID)
-			<-sem
-			if err != nil {
-				mu.Lock()
-				errs = append(errs, err)
-				mu.Unlock()
-				return
+	for _, number := range numbers {
+		srv, err := c.Get(ctx, number)
+		if err != nil {
+			if !IsNotFound(err) {
+				return nil, fmt.Errorf("error getting servers: %w", err)
 			}
-			mu.Lock()
-			servers = append(servers, srv)
-			mu.Unlock()
-		}(id)
+			errs = append(errs, err)
+			continue
+		}
+		servers = append(servers, *srv)
 	}
-	wg.Wait()
 	if len(errs) > 0 {
-		return nil, fmt.Errorf("FetchServersByIDs errors: %v", errs)
+		return nil, fmt.Errorf("error getting servers: %w", errors.Join(errs...))
 	}
 	sort.Slice(servers, func(i, j int) bool {
 		return servers[i].Number < servers[j].Number
 	})
 	return servers, nil
 }
+
+type renameRequest struct {
+	ServerName string `form:"server_name"`
+}
+
+func (c serverService) Rename(ctx context.Context, number int, name string) (*Server, error) {
+	endpoint := fmt.Sprintf("/server/%d", number)
+	resp, err := c.doForm(ctx, "POST", endpoint, renameRequest{ServerName: name})
+	if err != nil {
+		return nil, fmt.Errorf("error renaming server %d to %s: %w", number, name, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return nil, newRobotError(resp)
+	}
+	var renameResp struct {
+		Server Server `json:"server"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&renameResp); err != nil {
+		return nil, fmt.Errorf("error parsing rename response: %w", err)
+	}
+	return &renameResp.Server, nil
+}
@@ -0,0 +1,157 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"golang.org/x/sync/singleflight"
+)
+
+const serverCacheTTL = 5 * time.Minute
+
+// serverCache holds the result of a single GET /server listing, indexed by
+// server number, main IP and name, so that resources translating server
+// numbers into IPs do not each cost a request. Concurrent misses share one
+// request, and the cache is dropped after any mutating call that can change
+// the listing.
+type serverCache struct {
+	mu      sync.RWMutex
+	listing *serverListing
+
+	group singleflight.Group
+}
+
+// serverListing is one fetched server listing. It is never modified once
+// built, so lookups can use it while the cache is invalidated or refilled.
+type serverListing struct {
+	fetched  time.Time
+	servers  []Server
+	byNumber map[int]Server
+	byIP     map[string]Server
+	byName   map[string][]Server
+}
+
+func newServerListing(servers []Server) *serverListing {
+	l := &serverListing{
+		fetched:  time.Now(),
+		servers:  servers,
+		byNumber: make(map[int]Server, len(servers)),
+		byIP:     make(map[string]Server, len(servers)),
+		byName:   make(map[string][]Server, len(servers)),
+	}
+	for _, s := range servers {
+		l.byNumber[s.Number] = s
+		l.byIP[s.IP] = s
+		l.byName[s.ServerName] = append(l.byName[s.ServerName], s)
+	}
+	return l
+}
+
+func (sc *serverCache) get() (*serverListing, bool) {
+	sc.mu.RLock()
+	defer sc.mu.RUnlock()
+	if sc.listing == nil || time.Since(sc.listing.fetched) > serverCacheTTL {
+		return nil, false
+	}
+	return sc.listing, true
+}
+
+func (sc *serverCache) store(l *serverListing) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.listing = l
+}
+
+func (sc *serverCache) invalidate() {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.listing = nil
+}
+
+// List returns all servers of the account from the per-run cache, fetching
+// them with a single request if necessary.
+func (c serverService) List(ctx context.Context) ([]Server, error) {
+	l, err := c.listing(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return append([]Server(nil), l.servers...), nil
+}
+
+// listing returns the cached server listing, fetching it if necessary.
+// Lookups must use the returned listing rather than the cache, which can be
+// invalidated at any time.
+func (c serverService) listing(ctx context.Context) (*serverListing, error) {
+	if l, ok := c.servers.get(); ok {
+		return l, nil
+	}
+	// The shared fetch must not be aborted by the cancellation of whichever
+	// caller happened to start it; every caller still returns on its own ctx.
+	ch := c.servers.group.DoChan("servers", func() (interface{}, error) {
+		servers, err := c.fetchAll(context.WithoutCancel(ctx))
+		if err != nil {
+			return nil, err
+		}
+		l := newServerListing(servers)
+		c.servers.store(l)
+		tflog.SubsystemDebug(c.LogContext(ctx, SubsystemClient), SubsystemClient, "Cached server listing", map[string]interface{}{
+			"servers": len(servers),
+		})
+		return l, nil
+	})
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-ch:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.(*serverListing), nil
+	}
+}
+
+// Get returns the server with the given number from the per-run cache.
+func (c serverService) Get(ctx context.Context, number int) (*Server, error) {
+	l, err := c.listing(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if s, ok := l.byNumber[number]; ok {
+		return &s, nil
+	}
+	return nil, serverNotFound(fmt.Sprintf("server %d not found", number))
+}
+
+// GetByIP returns the server with the given main IP from the per-run cache.
+func (c serverService) GetByIP(ctx context.Context, ip string) (*Server, error) {
+	l, err := c.listing(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if s, ok := l.byIP[ip]; ok {
+		return &s, nil
+	}
+	return nil, serverNotFound(fmt.Sprintf("server with IP %s not found", ip))
+}
+
+// ListByName returns all servers with the given name from the per-run cache.
+func (c serverService) ListByName(ctx context.Context, name string) ([]Server, error) {
+	l, err := c.listing(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return append([]Server(nil), l.byName[name]...), nil
+}
+
+// InvalidateServerCache drops the cached server listing.
+func (c *HetznerRobotClient) InvalidateServerCache() {
+	c.servers.invalidate()
+}
+
+func serverNotFound(message string) error {
+	return &RobotError{StatusCode: http.StatusNotFound, Code: "SERVER_NOT_FOUND", Message: message}
+}
@@ -0,0 +1,125 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"golang.org/x/crypto/ssh"
+)
+
+func (c *HetznerRobotClient) rescueSSHAddr(serverIP string) string {
+	return net.JoinHostPort(serverIP, strconv.Itoa(c.Config.RescueSSHPort))
+}
+
+// dialSSH connects to the rescue system of a server as root. The TCP dial and
+// the SSH handshake are both aborted when ctx is done.
+func (c *HetznerRobotClient) dialSSH(ctx context.Context, serverIP, password string) (*ssh.Client, error) {
+	sshConfig := &ssh.ClientConfig{
+		User:            "root",
+		Auth:            []ssh.AuthMethod{ssh.Password(password)},
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+	}
+	addr := c.rescueSSHAddr(serverIP)
+
+	var dialer net.Dialer
+	netConn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	stop := context.AfterFunc(ctx, func() { netConn.Close() })
+	conn, chans, reqs, err := ssh.NewClientConn(netConn, addr, sshConfig)
+	if !stop() {
+		if err == nil {
+			conn.Close()
+		}
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		netConn.Close()
+		return nil, err
+	}
+	return ssh.NewClient(conn, chans, reqs), nil
+}
+
+// WaitForSSH polls the SSH port of the rescue system of serverIP until it
+// accepts connections, timeout expires or ctx is done.
+func (c installerService) WaitForSSH(ctx context.Context, serverIP string, timeout, interval time.Duration) error {
+	ctx = c.LogContext(ctx, SubsystemInstaller)
+	dialer := net.Dialer{Timeout: 5 * time.Second}
+	poller := Poller{
+		Description: "SSH on " + serverIP,
+		MinInterval: interval,
+		MaxInterval: interval,
+		Timeout:     timeout,
+		OnProgress: func(p PollProgress) {
+			tflog.SubsystemDebug(ctx, SubsystemInstaller, "Waiting for SSH", map[string]interface{}{
+				"server_ip": serverIP,
+				"attempt":   p.Attempt,
+				"retry_in":  p.Next.String(),
+				"error":     p.Status,
+			})
+		},
+	}
+	err := poller.Poll(ctx, func(ctx context.Context) (bool, string, error) {
+		conn, err := dialer.DialContext(ctx, "tcp", c.rescueSSHAddr(serverIP))
+		if err != nil {
+			return false, err.Error(), nil
+		}
+		conn.Close()
+		return true, "", nil
+	})
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		return fmt.Errorf("SSH not available on %s after %v", serverIP, timeout)
+	}
+	tflog.SubsystemInfo(ctx, SubsystemInstaller, "SSH is available", map[string]interface{}{"server_ip": serverIP})
+	return nil
+}
+
+// runSSHCommand runs cmd in a new session on conn and returns its combined
+// output. When ctx is done the connection is closed, which terminates the
+// session, and ctx.Err() is returned.
+func runSSHCommand(ctx context.Context, conn *ssh.Client, cmd string) ([]byte, error) {
+	session, err := conn.NewSession()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create SSH session: %w", err)
+	}
+	defer session.Close()
+
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	output, err := session.CombinedOutput(cmd)
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return output, ctxErr
+	}
+	return output, err
+}
+
+// isDisconnect reports whether err only means that the remote side went away
+// without reporting an exit status, as it does when a command reboots the
+// machine.
+func isDisconnect(err error) bool {
+	var exitMissing *ssh.ExitMissingError
+	return errors.As(err, &exitMissing) || errors.Is(err, net.ErrClosed) || strings.Contains(err.Error(), "EOF")
+}
+
+// redactSecrets replaces every occurrence of the given secrets in s, so that
+// command output can be safely included in error messages.
+func redactSecrets(s string, secrets ...string) string {
+	for _, secret := range secrets {
+		if secret != "" {
+			s = strings.ReplaceAll(s, secret, "***")
+		}
+	}
+	return s
+}
@@ -0,0 +1,208 @@
+package client_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/client"
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/robotfake"
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/shared"
+)
+
+func writePEM(t *testing.T, name, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func okHandler(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+}
+
+func TestClientSendsUserAgent(t *testing.T) {
+	fake := robotfake.New(robotfake.Options{})
+	defer fake.Close()
+	fake.AddServer(robotfake.Server{Number: 321})
+
+	c := newTestClient(t, fake, 0)
+	c.Config.UserAgent = "Terraform/1.9.0 terraform-provider-hetznerrobot/1.2.3"
+	if _, err := c.Servers().Fetch(context.Background(), 321); err != nil {
+		t.Fatal(err)
+	}
+	if ua := fake.Requests()[0].UserAgent; ua != c.Config.UserAgent {
+		t.Errorf("User-Agent = %q", ua)
+	}
+}
+
+func TestClientTrustsCAFile(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(okHandler))
+	defer srv.Close()
+	caFile := writePEM(t, "ca.pem", "CERTIFICATE", srv.Certificate().Raw)
+
+	untrusting, err := client.NewHetznerRobotClient(&shared.ProviderConfig{BaseURL: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := untrusting.DoRequest(context.Background(), "GET", "/", nil, ""); err == nil {
+		t.Fatal("request to a server with an unknown CA succeeded")
+	}
+
+	c, err := client.NewHetznerRobotClient(&shared.ProviderConfig{BaseURL: srv.URL, CACertFile: caFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := c.DoRequest(context.Background(), "GET", "/", nil, "")
+	if err != nil {
+		t.Fatalf("DoRequest: %v", err)
+	}
+	resp.Body.Close()
+}
+
+func TestClientPresentsClientCertificate(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "terraform"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	var presented string
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		presented = r.TLS.PeerCertificates[0].Subject.CommonName
+		mu.Unlock()
+	}))
+	srv.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	srv.StartTLS()
+	defer srv.Close()
+
+	c, err := client.NewHetznerRobotClient(&shared.ProviderConfig{
+		BaseURL:        srv.URL,
+		CACertFile:     writePEM(t, "ca.pem", "CERTIFICATE", srv.Certificate().Raw),
+		ClientCertFile: writePEM(t, "client.pem", "CERTIFICATE", der),
+		ClientKeyFile:  writePEM(t, "client-key.pem", "EC PRIVATE KEY", keyDER),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := c.DoRequest(context.Background(), "GET", "/", nil, "")
+	if err != nil {
+		t.Fatalf("DoRequest: %v", err)
+	}
+	resp.Body.Close()
+	mu.Lock()
+	defer mu.Unlock()
+	if presented != "terraform" {
+		t.Errorf("server saw client certificate %q", presented)
+	}
+}
+
+func TestClientUsesProxy(t *testing.T) {
+	fake := robotfake.New(robotfake.Options{})
+	defer fake.Close()
+	fake.AddServer(robotfake.Server{Number: 321})
+
+	var mu sync.Mutex
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		proxied = append(proxied, r.URL.String())
+		mu.Unlock()
+		r.RequestURI = ""
+		resp, err := http.DefaultTransport.RoundTrip(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		defer resp.Body.Close()
+		w.WriteHeader(resp.StatusCode)
+		_, _ = io.Copy(w, resp.Body)
+	}))
+	defer proxy.Close()
+
+	c, err := client.NewHetznerRobotClient(&shared.ProviderConfig{
+		Username: fake.Username(),
+		Password: fake.Password(),
+		BaseURL:  fake.URL(),
+		ProxyURL: proxy.URL,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Servers().Fetch(context.Background(), 321); err != nil {
+		t.Fatalf("Servers().Fetch: %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(proxied) != 1 || proxied[0] != fake.URL()+"/server/321" {
+		t.Errorf("proxied %q", proxied)
+	}
+}
+
+func TestClientRequestTimeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	c, err := client.NewHetznerRobotClient(&shared.ProviderConfig{
+		BaseURL:        srv.URL,
+		RequestTimeout: 50 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	if _, err := c.DoRequest(context.Background(), "POST", "/", nil, ""); err == nil {
+		t.Fatal("request to a hung server succeeded")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("request returned after %v", elapsed)
+	}
+}
+
+func TestNewClientRejectsInvalidTransportSettings(t *testing.T) {
+	for name, config := range map[string]shared.ProviderConfig{
+		"proxy scheme":     {ProxyURL: "ftp://proxy.example.com"},
+		"missing CA file":  {CACertFile: filepath.Join(t.TempDir(), "missing.pem")},
+		"cert without key": {ClientCertFile: "client.pem"},
+	} {
+		if _, err := client.NewHetznerRobotClient(&config); err == nil {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+}
@@ -1,14 +1,10 @@
 package client
 
 import (
-	"hcloud-robot-provider/shared"
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/shared"
 	"net/http"
 )
 
-type NotFoundError struct {
-	Message string
-}
-
 type VSwitchCloudNetwork struct {
 	ID      int    `json:"id"`
 	IP      string `json:"ip"`
@@ -27,11 +23,50 @@ type Server struct {
 	Status     string `json:"status"`
 	Cancelled  bool   `json:"cancelled"`
 	PaidUntil  string `json:"paid_until"`
+
+	IPs     []string       `json:"ip"`
+	Subnets []ServerSubnet `json:"subnet"`
+
+	// The remaining fields are only returned for a single server, i.e. by
+	// ServerService.Fetch, and are zero in listings.
+	Reset            bool `json:"reset"`
+	Rescue           bool `json:"rescue"`
+	VNC              bool `json:"vnc"`
+	Windows          bool `json:"windows"`
+	Plesk            bool `json:"plesk"`
+	CPanel           bool `json:"cpanel"`
+	WOL              bool `json:"wol"`
+	HotSwap          bool `json:"hot_swap"`
+	LinkedStoragebox *int `json:"linked_storagebox"`
+}
+
+// Capabilities returns the capability flags of a server fetched with
+// ServerService.Fetch, keyed by their Robot names.
+func (s Server) Capabilities() map[string]bool {
+	return map[string]bool{
+		"reset":    s.Reset,
+		"rescue":   s.Rescue,
+		"vnc":      s.VNC,
+		"windows":  s.Windows,
+		"plesk":    s.Plesk,
+		"cpanel":   s.CPanel,
+		"wol":      s.WOL,
+		"hot_swap": s.HotSwap,
+	}
+}
+
+type ServerSubnet struct {
+	IP   string `json:"ip"`
+	Mask string `json:"mask"`
 }
 
 type HetznerRobotClient struct {
 	Config *shared.ProviderConfig
 	Client *http.Client
+
+	limiter *rateLimiter
+	audit   *auditLog
+	servers serverCache
 }
 
 type VSwitch struct {
@@ -66,45 +101,37 @@ type VSwitchCloudNet struct {
 
 type HetznerRobotFirewall struct {
 	IP                       string                    `json:"ip"`
-	WhitelistHetznerServices bool                      `json:"whitelist_hos"`
-	Status                   string                    `json:"status"`
-	Rules                    HetznerRobotFirewallRules `json:"rules"`
+	WhitelistHetznerServices bool                      `json:"whitelist_hos" form:"whitelist_hos"`
+	Status                   string                    `json:"status" form:"status"`
+	Rules                    HetznerRobotFirewallRules `json:"rules" form:"rules"`
 }
 
 type HetznerRobotFirewallRules struct {
-	Input []HetznerRobotFirewallRule `json:"input"`
+	Input []HetznerRobotFirewallRule `json:"input" form:"input"`
 }
 
 type HetznerRobotFirewallRule struct {
-	Name     string `json:"name,omitempty"`
-	SrcIP    string `json:"src_ip,omitempty"`
-	SrcPort  string `json:"src_port,omitempty"`
-	DstIP    string `json:"dst_ip,omitempty"`
-	DstPort  string `json:"dst_port,omitempty"`
-	Protocol string `json:"protocol,omitempty"`
-	TCPFlags string `json:"tcp_flags,omitempty"`
-	Action   string `json:"action"`
+	IPVersion string `json:"ip_version,omitempty" form:"ip_version,omitempty"`
+	Name      string `json:"name,omitempty" form:"name,omitempty"`
+	SrcIP     string `json:"src_ip,omitempty" form:"src_ip,omitempty"`
+	SrcPort   string `json:"src_port,omitempty" form:"src_port,omitempty"`
+	DstIP     string `json:"dst_ip,omitempty" form:"dst_ip,omitempty"`
+	DstPort   string `json:"dst_port,omitempty" form:"dst_port,omitempty"`
+	Protocol  string `json:"protocol,omitempty" form:"protocol,omitempty"`
+	TCPFlags  string `json:"tcp_flags,omitempty" form:"tcp_flags,omitempty"`
+	Action    string `json:"action" form:"action"`
 }
 
 type HetznerRobotFirewallResponse struct {
 	Firewall HetznerRobotFirewall `json:"firewall"`
 }
 
-type HetznerResetResponse struct {
-	Reset struct {
-		Type string `json:"type"`
-	} `json:"reset"`
-}
-
-type HetznerRescueResponse struct {
-	Rescue struct {
-		ServerIP string `json:"server_ip"`
-		Password string `json:"password"`
-	} `json:"rescue"`
-}
-
-type HetznerRenameResponse struct {
-	Server struct {
-		ServerName string `json:"server_name"`
-	} `json:"server"`
+// Rescue is the rescue system configuration of a server.
+type Rescue struct {
+	ServerIP     string `json:"server_ip"`
+	ServerNumber int    `json:"server_number"`
+	Active       bool   `json:"active"`
+	// Password is the root password of the rescue system. Robot only returns
+	// it in the response that activates the rescue system.
+	Password string `json:"password"`
 }
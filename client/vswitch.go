@@ -3,30 +3,26 @@ package client
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
-	"net/url"
 	"sort"
-	"strconv"
-	"strings"
 	"sync"
 	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 )
 
-func (c *HetznerRobotClient) FetchVSwitchByIDWithContext(ctx context.Context, id string) (*VSwitch, error) {
-	resp, err := c.DoRequest("GET", fmt.Sprintf("/vswitch/%s", id), nil, "")
+func (c vswitchService) Get(ctx context.Context, id int) (*VSwitch, error) {
+	resp, err := c.DoRequest(ctx, "GET", fmt.Sprintf("/vswitch/%d", id), nil, "")
 	if err != nil {
 		return nil, fmt.Errorf("error fetching VSwitch: %w", err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode == http.StatusNotFound {
-		return nil, nil
-	}
 	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("error fetching VSwitch: status %d, body %s", resp.StatusCode, string(bodyBytes))
+		return nil, fmt.Errorf("error fetching VSwitch: %w", newRobotError(resp))
 	}
 
 	var vswitch VSwitch
@@ -36,7 +32,7 @@ func (c *HetznerRobotClient) FetchVSwitchByIDWithContext(ctx context.Context, id
 	return &vswitch, nil
 }
 
-func (c *HetznerRobotClient) FetchVSwitchesByIDs(ids []string) ([]VSwitch, error) {
+func (c vswitchService) GetMany(ctx context.Context, ids []int) ([]VSwitch, error) {
 	var (
 		vswitches []VSwitch
 		mu        sync.Mutex
@@ -44,15 +40,20 @@ func (c *HetznerRobotClient) FetchVSwitchesByIDs(ids []string) ([]VSwitch, error
 		errs      []error
 	)
 	sem := make(chan struct{}, 10)
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
 	for _, id := range ids {
 		wg.Add(1)
-		go func(vswitchID string) {
+		go func(vswitchID int) {
 			defer wg.Done()
-			sem <- struct{}{}
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				mu.Lock()
+				errs = append(errs, ctx.Err())
+				mu.Unlock()
+				return
+			}
 			defer func() { <-sem }()
-			vswitch, err := c.FetchVSwitchByIDWithContext(ctx, vswitchID)
+			vswitch, err := c.Get(ctx, vswitchID)
 			if err != nil {
 				mu.Lock()
 				errs = append(errs, err)
@@ -65,12 +66,15 @@ func (c *HetznerRobotClient) FetchVSwitchesByIDs(ids []string) ([]VSwitch, error
 		}(id)
 	}
 	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	if len(errs) > 0 {
 		firstErrors := errs
 		if len(errs) > 5 {
 			firstErrors = errs[:5]
 		}
-		return nil, fmt.Errorf("errors occurred: %v (and %d more)", firstErrors, len(errs)-len(firstErrors))
+		return nil, fmt.Errorf("errors occurred: %w (and %d more)", errors.Join(firstErrors...), len(errs)-len(firstErrors))
 	}
 	sort.Slice(vswitches, func(i, j int) bool {
 		return vswitches[i].ID < vswitches[j].ID
@@ -78,15 +82,14 @@ func (c *HetznerRobotClient) FetchVSwitchesByIDs(ids []string) ([]VSwitch, error
 	return vswitches, nil
 }
 
-func (c *HetznerRobotClient) FetchAllVSwitches(ctx context.Context) ([]VSwitch, error) {
-	resp, err := c.DoRequest("GET", "/vswitch", nil, "")
+func (c vswitchService) List(ctx context.Context) ([]VSwitch, error) {
+	resp, err := c.DoRequest(ctx, "GET", "/vswitch", nil, "")
 	if err != nil {
 		return nil, fmt.Errorf("error fetching all vSwitches: %w", err)
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("error fetching vSwitches: status %d, body %s", resp.StatusCode, string(bodyBytes))
+		return nil, fmt.Errorf("error fetching vSwitches: %w", newRobotError(resp))
 	}
 	var vswitches []VSwitch
 	if err := json.NewDecoder(resp.Body).Decode(&vswitches); err != nil {
@@ -95,18 +98,37 @@ func (c *HetznerRobotClient) FetchAllVSwitches(ctx context.Context) ([]VSwitch,
 	return vswitches, nil
 }
 
-func (c *HetznerRobotClient) CreateVSwitch(ctx context.Context, name string, vlan int) (*VSwitch, error) {
-	data := url.Values{}
-	data.Set("name", name)
-	data.Set("vlan", strconv.Itoa(vlan))
-	resp, err := c.DoRequest("POST", "/vswitch", strings.NewReader(data.Encode()), "application/x-www-form-urlencoded")
+// vswitchRequest creates or updates a vSwitch. VLAN is always sent on
+// creation, even if 0, and on update only if it changed.
+type vswitchRequest struct {
+	Name string `form:"name"`
+	VLAN *int   `form:"vlan"`
+}
+
+type cancellationRequest struct {
+	CancellationDate string `form:"cancellation_date"`
+}
+
+type vswitchServersRequest struct {
+	Servers []int `form:"server"`
+}
+
+func newVSwitchServersRequest(servers []VSwitchServer) vswitchServersRequest {
+	var req vswitchServersRequest
+	for _, server := range servers {
+		req.Servers = append(req.Servers, server.ServerNumber)
+	}
+	return req
+}
+
+func (c vswitchService) Create(ctx context.Context, name string, vlan int) (*VSwitch, error) {
+	resp, err := c.doForm(ctx, "POST", "/vswitch", vswitchRequest{Name: name, VLAN: &vlan})
 	if err != nil {
 		return nil, fmt.Errorf("error creating VSwitch: %w", err)
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("error creating VSwitch: status %d, body %s", resp.StatusCode, string(bodyBytes))
+		return nil, fmt.Errorf("error creating VSwitch: %w", newRobotError(resp))
 	}
 	var vswitch VSwitch
 	if err := json.NewDecoder(resp.Body).Decode(&vswitch); err != nil {
@@ -115,51 +137,38 @@ func (c *HetznerRobotClient) CreateVSwitch(ctx context.Context, name string, vla
 	return &vswitch, nil
 }
 
-func (c *HetznerRobotClient) UpdateVSwitch(ctx context.Context, id, name string, vlan int, oldVlan int) error {
-	data := url.Values{}
-	data.Set("name", name)
+func (c vswitchService) Update(ctx context.Context, id int, name string, vlan int, oldVlan int) error {
+	ctx = c.LogContext(ctx, SubsystemVSwitch)
+	data := vswitchRequest{Name: name}
 
 	if vlan != oldVlan {
-		data.Set("vlan", strconv.Itoa(vlan))
-		fmt.Printf("VLAN changed, including in update request: %d -> %d\n", oldVlan, vlan)
+		data.VLAN = &vlan
+		tflog.SubsystemDebug(ctx, SubsystemVSwitch, "VLAN changed, including it in update request", map[string]interface{}{
+			"vswitch_id": id,
+			"old_vlan":   oldVlan,
+			"new_vlan":   vlan,
+		})
 	} else {
-		fmt.Printf("VLAN has not changed, sending only name update\n")
+		tflog.SubsystemDebug(ctx, SubsystemVSwitch, "VLAN has not changed, sending only name update", map[string]interface{}{
+			"vswitch_id": id,
+		})
 	}
 
-	resp, err := c.DoRequest("POST", fmt.Sprintf("/vswitch/%s", id), strings.NewReader(data.Encode()), "application/x-www-form-urlencoded")
+	resp, err := c.doForm(ctx, "POST", fmt.Sprintf("/vswitch/%d", id), data)
 	if err != nil {
 		return fmt.Errorf("error updating VSwitch: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		bodyStr := string(bodyBytes)
-
-		switch resp.StatusCode {
-		case http.StatusBadRequest:
-			return fmt.Errorf("error updating VSwitch: INVALID_INPUT - %s", bodyStr)
-		case http.StatusNotFound:
-			return fmt.Errorf("error updating VSwitch: NOT_FOUND - %s", bodyStr)
-		case http.StatusConflict:
-			if strings.Contains(bodyStr, "VSWITCH_IN_PROCESS") {
-				return fmt.Errorf("error updating VSwitch: VSWITCH_IN_PROCESS - %s", bodyStr)
-			}
-			if strings.Contains(bodyStr, "VSWITCH_VLAN_NOT_UNIQUE") {
-				return fmt.Errorf("error updating VSwitch: VSWITCH_VLAN_NOT_UNIQUE - %s", bodyStr)
-			}
-		default:
-			return fmt.Errorf("error updating VSwitch: status %d, body %s", resp.StatusCode, bodyStr)
-		}
+		return fmt.Errorf("error updating VSwitch: %w", newRobotError(resp))
 	}
 
 	return nil
 }
 
-func (c *HetznerRobotClient) DeleteVSwitch(ctx context.Context, id string, cancellationDate string) error {
-	data := url.Values{}
-	data.Set("cancellation_date", cancellationDate)
-	resp, err := c.DoRequest("DELETE", fmt.Sprintf("/vswitch/%s", id), strings.NewReader(data.Encode()), "application/x-www-form-urlencoded")
+func (c vswitchService) Delete(ctx context.Context, id int, cancellationDate string) error {
+	resp, err := c.doForm(ctx, "DELETE", fmt.Sprintf("/vswitch/%d", id), cancellationRequest{CancellationDate: cancellationDate})
 	if err != nil {
 		return fmt.Errorf("error deleting VSwitch: %w", err)
 	}
@@ -170,18 +179,13 @@ func (c *HetznerRobotClient) DeleteVSwitch(ctx context.Context, id string, cance
 		}
 	}(resp.Body)
 	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("error deleting VSwitch: status %d, body %s", resp.StatusCode, string(bodyBytes))
+		return fmt.Errorf("error deleting VSwitch: %w", newRobotError(resp))
 	}
 	return nil
 }
 
-func (c *HetznerRobotClient) AddVSwitchServers(ctx context.Context, id string, servers []VSwitchServer) error {
-	data := url.Values{}
-	for _, server := range servers {
-		data.Add("server[]", strconv.Itoa(server.ServerNumber))
-	}
-	resp, err := c.DoRequest("POST", fmt.Sprintf("/vswitch/%s/server", id), strings.NewReader(data.Encode()), "application/x-www-form-urlencoded")
+func (c vswitchService) AddServers(ctx context.Context, id int, servers []VSwitchServer) error {
+	resp, err := c.doForm(ctx, "POST", fmt.Sprintf("/vswitch/%d/server", id), newVSwitchServersRequest(servers))
 	if err != nil {
 		return fmt.Errorf("error adding servers to VSwitch: %w", err)
 	}
@@ -192,71 +196,62 @@ func (c *HetznerRobotClient) AddVSwitchServers(ctx context.Context, id string, s
 		}
 	}(resp.Body)
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("error adding servers to VSwitch: status %d, body %s", resp.StatusCode, string(bodyBytes))
+		return fmt.Errorf("error adding servers to VSwitch: %w", newRobotError(resp))
 	}
 	return nil
 }
 
-func (c *HetznerRobotClient) RemoveVSwitchServers(ctx context.Context, id string, servers []VSwitchServer) error {
-	data := url.Values{}
-	for _, server := range servers {
-		data.Add("server[]", strconv.Itoa(server.ServerNumber))
-	}
-	resp, err := c.DoRequest("DELETE", fmt.Sprintf("/vswitch/%s/server", id), strings.NewReader(data.Encode()), "application/x-www-form-urlencoded")
+func (c vswitchService) RemoveServers(ctx context.Context, id int, servers []VSwitchServer) error {
+	resp, err := c.doForm(ctx, "DELETE", fmt.Sprintf("/vswitch/%d/server", id), newVSwitchServersRequest(servers))
 	if err != nil {
 		return fmt.Errorf("error removing servers from VSwitch: %w", err)
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("error removing servers from VSwitch: status %d, body %s", resp.StatusCode, string(bodyBytes))
-	}
-	return nil
-}
-
-func (c *HetznerRobotClient) SetVSwitchCancellation(ctx context.Context, id, cancellationDate string) error {
-	data := url.Values{}
-	data.Set("cancellation_date", cancellationDate)
-	resp, err := c.DoRequest("POST", fmt.Sprintf("/vswitch/%s/cancel", id), strings.NewReader(data.Encode()), "application/x-www-form-urlencoded")
-	if err != nil {
-		return fmt.Errorf("error setting cancellation date: %w", err)
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return fmt.Errorf("error removing servers from VSwitch: %w", newRobotError(resp))
 	}
 	return nil
 }
 
-func (c *HetznerRobotClient) WaitForVSwitchReady(ctx context.Context, id string, maxRetries int, waitTime time.Duration) error {
-	for i := 0; i < maxRetries; i++ {
-		vsw, err := c.FetchVSwitchByIDWithContext(ctx, id)
+func (c vswitchService) WaitReady(ctx context.Context, id int, timeout time.Duration) error {
+	ctx = c.LogContext(ctx, SubsystemVSwitch)
+	poller := c.newPoller(fmt.Sprintf("vSwitch %d to become ready", id), func(p PollProgress) {
+		tflog.SubsystemDebug(ctx, SubsystemVSwitch, "vSwitch is still processing", map[string]interface{}{
+			"vswitch_id": id,
+			"status":     p.Status,
+			"attempt":    p.Attempt,
+			"retry_in":   p.Next.String(),
+		})
+	})
+	poller.Timeout = timeout
+	return poller.Poll(ctx, func(ctx context.Context) (bool, string, error) {
+		vsw, err := c.Get(ctx, id)
 		if err != nil {
-			return fmt.Errorf("error fetching VSwitch while waiting: %w", err)
-		}
-
-		if vsw == nil {
-			return fmt.Errorf("vSwitch with ID %s not found", id)
+			return false, "", fmt.Errorf("error fetching VSwitch while waiting: %w", err)
 		}
 
-		allReady := true
+		var processing, failed []int
 		for _, server := range vsw.Servers {
-			fmt.Printf("Checking server %d status: %s\n", server.ServerNumber, server.Status)
-			if server.Status == "processing" {
-				allReady = false
-				break
+			tflog.SubsystemTrace(ctx, SubsystemVSwitch, "Checking vSwitch server status", map[string]interface{}{
+				"vswitch_id":    id,
+				"server_number": server.ServerNumber,
+				"status":        server.Status,
+			})
+			switch server.Status {
+			case "processing":
+				processing = append(processing, server.ServerNumber)
+			case "failed":
+				failed = append(failed, server.ServerNumber)
 			}
 		}
 
-		if allReady {
-			fmt.Println("vSwitch is now ready.")
-			return nil
+		if len(processing) > 0 {
+			return false, fmt.Sprintf("servers %v processing", processing), nil
 		}
-
-		fmt.Printf("vSwitch is still processing, retrying in %v seconds (%d/%d)...\n", waitTime.Seconds(), i+1, maxRetries)
-		time.Sleep(waitTime)
-	}
-
-	return fmt.Errorf("timeout waiting for vSwitch %s to become ready", id)
+		if len(failed) > 0 {
+			return false, "failed", fmt.Errorf("%w: servers %v could not be connected to vSwitch %d", ErrOperationFailed, failed, id)
+		}
+		tflog.SubsystemDebug(ctx, SubsystemVSwitch, "vSwitch is ready", map[string]interface{}{"vswitch_id": id})
+		return true, "ready", nil
+	})
 }
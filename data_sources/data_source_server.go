@@ -3,11 +3,15 @@ package data_sources
 import (
 	"context"
 	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/client"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
-	"hcloud-robot-provider/client"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
 func DataSourceServers() *schema.Resource {
@@ -19,30 +23,213 @@ func DataSourceServers() *schema.Resource {
 				Optional: true,
 				Elem:     &schema.Schema{Type: schema.TypeInt},
 			},
+			"name": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"name_regex"},
+				Description:   "Only servers with exactly this name.",
+			},
+			"name_regex": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringIsValidRegExp,
+				Description:  "Only servers whose name matches this regular expression.",
+			},
+			"ip": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.IsIPAddress,
+				Description:  "Only the server with this main or additional IP.",
+			},
+			"datacenter": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Only servers whose datacenter starts with this prefix, e.g. \"FSN1\" or \"FSN1-DC14\".",
+			},
+			"product": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Only servers of this product, e.g. \"AX41-NVMe\".",
+			},
+			"status": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice([]string{"ready", "in process"}, false),
+				Description:  "Only servers with this status (\"ready\" or \"in process\").",
+			},
+			"cancelled": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Description: "Only cancelled (true) or not cancelled (false) servers.",
+			},
+			"include_details": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     true,
+				Description: "Fetch each matching server individually to fill in capabilities and linked_storagebox, at the cost of one request per server. Set it to false to save the requests on large accounts; capabilities is then empty and linked_storagebox 0.",
+			},
+			"single": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Description: "Fail unless exactly one server matches, and expose it as server.",
+			},
 			"servers": {
 				Type:     schema.TypeList,
 				Computed: true,
+				Elem:     serverSchema(),
+			},
+			"server": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "The matching server if single is set.",
+				Elem:        serverSchema(),
+			},
+		},
+	}
+}
+
+func serverSchema() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"ip":         {Type: schema.TypeString, Computed: true},
+			"ipv6_net":   {Type: schema.TypeString, Computed: true},
+			"number":     {Type: schema.TypeInt, Computed: true},
+			"name":       {Type: schema.TypeString, Computed: true},
+			"product":    {Type: schema.TypeString, Computed: true},
+			"datacenter": {Type: schema.TypeString, Computed: true},
+			"traffic":    {Type: schema.TypeString, Computed: true},
+			"status":     {Type: schema.TypeString, Computed: true},
+			"cancelled":  {Type: schema.TypeBool, Computed: true},
+			"paid_until": {Type: schema.TypeString, Computed: true},
+			"ips": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Single IP addresses of the server.",
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"subnets": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Subnets routed to the server.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"ip":   {Type: schema.TypeString, Computed: true},
+						"mask": {Type: schema.TypeString, Computed: true},
+					},
+				},
+			},
+			"capabilities": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Which Robot features are available for the server. Empty if include_details is false.",
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
-						"ip":         {Type: schema.TypeString, Computed: true},
-						"ipv6_net":   {Type: schema.TypeString, Computed: true},
-						"number":     {Type: schema.TypeInt, Computed: true},
-						"name":       {Type: schema.TypeString, Computed: true},
-						"product":    {Type: schema.TypeString, Computed: true},
-						"datacenter": {Type: schema.TypeString, Computed: true},
-						"traffic":    {Type: schema.TypeString, Computed: true},
-						"status":     {Type: schema.TypeString, Computed: true},
-						"cancelled":  {Type: schema.TypeBool, Computed: true},
-						"paid_until": {Type: schema.TypeString, Computed: true},
+						"reset":    {Type: schema.TypeBool, Computed: true},
+						"rescue":   {Type: schema.TypeBool, Computed: true},
+						"vnc":      {Type: schema.TypeBool, Computed: true},
+						"windows":  {Type: schema.TypeBool, Computed: true},
+						"plesk":    {Type: schema.TypeBool, Computed: true},
+						"cpanel":   {Type: schema.TypeBool, Computed: true},
+						"wol":      {Type: schema.TypeBool, Computed: true},
+						"hot_swap": {Type: schema.TypeBool, Computed: true},
 					},
 				},
 			},
+			"linked_storagebox": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "ID of the storage box linked to the server, or 0 if there is none or include_details is false.",
+			},
 		},
 	}
 }
 
+// serverFilter holds the filter arguments of the data source. Unset filters
+// match every server.
+type serverFilter struct {
+	name       string
+	nameRegex  *regexp.Regexp
+	ip         string
+	datacenter string
+	product    string
+	status     string
+	cancelled  *bool
+}
+
+func serverFilterFromData(d *schema.ResourceData) (serverFilter, error) {
+	f := serverFilter{
+		name:       d.Get("name").(string),
+		ip:         d.Get("ip").(string),
+		datacenter: d.Get("datacenter").(string),
+		product:    d.Get("product").(string),
+		status:     d.Get("status").(string),
+	}
+	if expr := d.Get("name_regex").(string); expr != "" {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return f, fmt.Errorf("invalid name_regex: %w", err)
+		}
+		f.nameRegex = re
+	}
+	f.cancelled = optionalBool(d, "cancelled")
+	return f, nil
+}
+
+// optionalBool returns the value of a bool filter, or nil if it is unset.
+// Unlike a bool, the pointer can tell false from unset.
+func optionalBool(d *schema.ResourceData, key string) *bool {
+	v, ok := d.GetOkExists(key)
+	if !ok {
+		return nil
+	}
+	b := v.(bool)
+	return &b
+}
+
+func (f serverFilter) matches(s client.Server) bool {
+	switch {
+	case f.name != "" && s.ServerName != f.name:
+		return false
+	case f.nameRegex != nil && !f.nameRegex.MatchString(s.ServerName):
+		return false
+	case f.ip != "" && s.IP != f.ip && !slices.Contains(s.IPs, f.ip):
+		return false
+	case f.datacenter != "" && !strings.HasPrefix(s.Datacenter, f.datacenter):
+		return false
+	case f.product != "" && s.Product != f.product:
+		return false
+	case f.status != "" && s.Status != f.status:
+		return false
+	case f.cancelled != nil && s.Cancelled != *f.cancelled:
+		return false
+	}
+	return true
+}
+
+// String describes the set filters, for the data source ID and errors.
+func (f serverFilter) String() string {
+	var parts []string
+	add := func(key, value string) {
+		if value != "" {
+			parts = append(parts, fmt.Sprintf("%s=%q", key, value))
+		}
+	}
+	add("name", f.name)
+	if f.nameRegex != nil {
+		add("name_regex", f.nameRegex.String())
+	}
+	add("ip", f.ip)
+	add("datacenter", f.datacenter)
+	add("product", f.product)
+	add("status", f.status)
+	if f.cancelled != nil {
+		add("cancelled", strconv.FormatBool(*f.cancelled))
+	}
+	return strings.Join(parts, ", ")
+}
+
 func dataSourceServersRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	hClient, ok := meta.(*client.HetznerRobotClient)
+	api, ok := meta.(client.RobotAPI)
 	if !ok {
 		return diag.Errorf("invalid client type")
 	}
@@ -51,44 +238,106 @@ func dataSourceServersRead(ctx context.Context, d *schema.ResourceData, meta int
 	for _, v := range rawIDs {
 		ids = append(ids, v.(int))
 	}
-	var (
-		servers []client.Server
-		err     error
-	)
+	filter, err := serverFilterFromData(d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	var servers []client.Server
 	if len(ids) == 0 {
-		servers, err = hClient.FetchAllServers()
+		servers, err = api.Servers().List(ctx)
 	} else {
-		servers, err = hClient.FetchServersByIDs(ids)
+		servers, err = api.Servers().GetMany(ctx, ids)
 	}
 	if err != nil {
 		return diag.FromErr(fmt.Errorf("failed to fetch servers: %w", err))
 	}
+	servers = slices.DeleteFunc(servers, func(s client.Server) bool { return !filter.matches(s) })
+
+	includeDetails := d.Get("include_details").(bool)
 	serverList := make([]map[string]interface{}, 0, len(servers))
 	for _, s := range servers {
-		serverList = append(serverList, map[string]interface{}{
-			"ip":         s.IP,
-			"ipv6_net":   s.IPv6Net,
-			"number":     s.Number,
-			"name":       s.ServerName,
-			"product":    s.Product,
-			"datacenter": s.Datacenter,
-			"traffic":    s.Traffic,
-			"status":     s.Status,
-			"cancelled":  s.Cancelled,
-			"paid_until": s.PaidUntil,
-		})
+		if includeDetails {
+			detail, err := api.Servers().Fetch(ctx, s.Number)
+			if err != nil {
+				return diag.FromErr(fmt.Errorf("failed to fetch details of server %d: %w", s.Number, err))
+			}
+			s = *detail
+		}
+		serverList = append(serverList, flattenServer(s, includeDetails))
 	}
 	if err := d.Set("servers", serverList); err != nil {
 		return diag.FromErr(err)
 	}
+
+	single := []map[string]interface{}{}
+	if d.Get("single").(bool) {
+		if len(servers) != 1 {
+			var numbers []string
+			for _, s := range servers {
+				numbers = append(numbers, strconv.Itoa(s.Number))
+			}
+			return diag.Diagnostics{{
+				Severity: diag.Error,
+				Summary:  fmt.Sprintf("Expected exactly one server, found %d", len(servers)),
+				Detail:   fmt.Sprintf("Filters: %s. Matching servers: [%s].", filter, strings.Join(numbers, ", ")),
+			}}
+		}
+		single = serverList
+	}
+	if err := d.Set("server", single); err != nil {
+		return diag.FromErr(err)
+	}
+
 	idStr := "all"
 	if len(ids) > 0 {
 		idStr = strings.Join(intSliceToStringSlice(ids), "-")
 	}
+	if f := filter.String(); f != "" {
+		idStr += "-" + strconv.Itoa(schema.HashString(f))
+	}
 	d.SetId(fmt.Sprintf("servers-%s", idStr))
 	return nil
 }
 
+func flattenServer(s client.Server, withDetails bool) map[string]interface{} {
+	subnets := make([]map[string]interface{}, 0, len(s.Subnets))
+	for _, subnet := range s.Subnets {
+		subnets = append(subnets, map[string]interface{}{
+			"ip":   subnet.IP,
+			"mask": subnet.Mask,
+		})
+	}
+	capabilities := []map[string]interface{}{}
+	linkedStoragebox := 0
+	if withDetails {
+		flags := make(map[string]interface{})
+		for name, available := range s.Capabilities() {
+			flags[name] = available
+		}
+		capabilities = append(capabilities, flags)
+		if s.LinkedStoragebox != nil {
+			linkedStoragebox = *s.LinkedStoragebox
+		}
+	}
+	return map[string]interface{}{
+		"ip":         s.IP,
+		"ipv6_net":   s.IPv6Net,
+		"number":     s.Number,
+		"name":       s.ServerName,
+		"product":    s.Product,
+		"datacenter": s.Datacenter,
+		"traffic":    s.Traffic,
+		"status":     s.Status,
+		"cancelled":  s.Cancelled,
+		"paid_until": s.PaidUntil,
+
+		"ips":               s.IPs,
+		"subnets":           subnets,
+		"capabilities":      capabilities,
+		"linked_storagebox": linkedStoragebox,
+	}
+}
+
 func intSliceToStringSlice(ints []int) []string {
 	out := make([]string, len(ints))
 	for i, v := range ints {
@@ -0,0 +1,86 @@
+package data_sources
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/client"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func DataSourceServerAddons() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceServerAddonsRead,
+		Schema: map[string]*schema.Schema{
+			"server_number": {
+				Type:        schema.TypeInt,
+				Required:    true,
+				Description: "Number of the server to list orderable addons for.",
+			},
+			"type": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Only addons of this type, e.g. \"ip_ipv4\" or \"subnet_ipv4\".",
+			},
+			"addons": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id":          {Type: schema.TypeString, Computed: true},
+						"name":        {Type: schema.TypeString, Computed: true},
+						"type":        {Type: schema.TypeString, Computed: true},
+						"location":    {Type: schema.TypeString, Computed: true},
+						"price_type":  {Type: schema.TypeString, Computed: true, Description: "\"monthly\" or \"hourly\"."},
+						"price_net":   {Type: schema.TypeString, Computed: true},
+						"price_gross": {Type: schema.TypeString, Computed: true},
+						"setup_net":   {Type: schema.TypeString, Computed: true},
+						"setup_gross": {Type: schema.TypeString, Computed: true},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceServerAddonsRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api, ok := meta.(client.RobotAPI)
+	if !ok {
+		return diag.Errorf("invalid client type")
+	}
+	number := d.Get("server_number").(int)
+	addonType := d.Get("type").(string)
+
+	products, err := api.Ordering().AddonProducts(ctx, number)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("failed to fetch addons of server %d: %w", number, err))
+	}
+	addons := make([]map[string]interface{}, 0, len(products))
+	for _, p := range products {
+		if addonType != "" && p.Type != addonType {
+			continue
+		}
+		addons = append(addons, map[string]interface{}{
+			"id":          p.ID,
+			"name":        p.Name,
+			"type":        p.Type,
+			"location":    p.Price.Location,
+			"price_type":  p.Price.Type,
+			"price_net":   p.Price.Price.Net,
+			"price_gross": p.Price.Price.Gross,
+			"setup_net":   p.Price.PriceSetup.Net,
+			"setup_gross": p.Price.PriceSetup.Gross,
+		})
+	}
+	if err := d.Set("addons", addons); err != nil {
+		return diag.FromErr(err)
+	}
+
+	id := fmt.Sprintf("server-addons-%d", number)
+	if addonType != "" {
+		id += "-" + addonType
+	}
+	d.SetId(id)
+	return nil
+}
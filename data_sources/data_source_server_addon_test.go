@@ -0,0 +1,43 @@
+package data_sources_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/data_sources"
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/robotfake"
+)
+
+func TestServerAddonDataSource(t *testing.T) {
+	fake := robotfake.New(robotfake.Options{})
+	defer fake.Close()
+	fake.AddServer(robotfake.Server{Number: 321, IP: "203.0.113.10", Datacenter: "HEL1-DC2"})
+	c := fake.NewClient(t)
+
+	r := data_sources.DataSourceServerAddons()
+	d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{"server_number": 321})
+	if diags := r.ReadContext(context.Background(), d, c); diags.HasError() {
+		t.Fatal(diags)
+	}
+	if n := d.Get("addons.#").(int); n != len(robotfake.AddonProducts) {
+		t.Errorf("%d addons, want %d", n, len(robotfake.AddonProducts))
+	}
+	if got := d.Get("addons.0.location"); got != "HEL1" {
+		t.Errorf("addons.0.location = %v", got)
+	}
+
+	d = schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{"server_number": 321, "type": "subnet_ipv4"})
+	if diags := r.ReadContext(context.Background(), d, c); diags.HasError() {
+		t.Fatal(diags)
+	}
+	if d.Get("addons.#").(int) != 1 || d.Get("addons.0.id") != "subnet_ipv4_29" {
+		t.Errorf("subnet addons = %v", d.Get("addons"))
+	}
+
+	d = schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{"server_number": 999})
+	if diags := r.ReadContext(context.Background(), d, c); !diags.HasError() {
+		t.Error("addons of an unknown server: no error")
+	}
+}
@@ -0,0 +1,259 @@
+package data_sources
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/client"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+// marketSortKeys maps the values of sort_by to the compared property.
+var marketSortKeys = map[string]func(client.MarketProduct) float64{
+	"price":         func(p client.MarketProduct) float64 { return parsePrice(p.Price) },
+	"memory":        func(p client.MarketProduct) float64 { return float64(p.MemorySize) },
+	"disk_capacity": func(p client.MarketProduct) float64 { return float64(p.HDDCount * p.HDDSize) },
+	"cpu_benchmark": func(p client.MarketProduct) float64 { return float64(p.CPUBenchmark) },
+}
+
+func DataSourceServerMarket() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceServerMarketRead,
+		Schema: map[string]*schema.Schema{
+			"min_ram": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "Only servers with at least this much memory, in GB.",
+			},
+			"cpu_regex": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringIsValidRegExp,
+				Description:  "Only servers whose CPU model matches this regular expression, e.g. \"Ryzen\".",
+			},
+			"min_disk_count": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "Only servers with at least this many disks.",
+			},
+			"min_disk_size": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "Only servers whose disks are each at least this large, in GB.",
+			},
+			"datacenter": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Only servers whose datacenter starts with this prefix, e.g. \"FSN1\" or \"FSN1-DC14\".",
+			},
+			"max_price": {
+				Type:         schema.TypeFloat,
+				Optional:     true,
+				ValidateFunc: validation.FloatAtLeast(0),
+				Description:  "Only servers whose current monthly net price is at most this many euros.",
+			},
+			"fixed_price": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Description: "Only servers with a fixed price (true) or a price that still drops (false).",
+			},
+			"sort_by": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "price",
+				ValidateFunc: validation.StringInSlice([]string{"price", "memory", "disk_capacity", "cpu_benchmark"}, false),
+				Description:  "Order of products: \"price\", \"memory\", \"disk_capacity\" (disk count times size) or \"cpu_benchmark\". Ties are broken by ID.",
+			},
+			"sort_descending": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Description: "Sort from the highest value down.",
+			},
+			"limit": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ValidateFunc: validation.IntAtLeast(1),
+				Description:  "Return at most this many products, after sorting.",
+			},
+			"products": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id":            {Type: schema.TypeInt, Computed: true},
+						"name":          {Type: schema.TypeString, Computed: true},
+						"description":   {Type: schema.TypeList, Computed: true, Elem: &schema.Schema{Type: schema.TypeString}},
+						"traffic":       {Type: schema.TypeString, Computed: true},
+						"dists":         {Type: schema.TypeList, Computed: true, Elem: &schema.Schema{Type: schema.TypeString}},
+						"langs":         {Type: schema.TypeList, Computed: true, Elem: &schema.Schema{Type: schema.TypeString}},
+						"cpu":           {Type: schema.TypeString, Computed: true},
+						"cpu_benchmark": {Type: schema.TypeInt, Computed: true},
+						"memory_size":   {Type: schema.TypeInt, Computed: true, Description: "Memory in GB."},
+						"disk_count":    {Type: schema.TypeInt, Computed: true},
+						"disk_size":     {Type: schema.TypeInt, Computed: true, Description: "Size of each disk in GB."},
+						"disk_text":     {Type: schema.TypeString, Computed: true},
+						"datacenter":    {Type: schema.TypeString, Computed: true},
+						"network_speed": {Type: schema.TypeString, Computed: true},
+						"monthly_net":   {Type: schema.TypeString, Computed: true},
+						"monthly_gross": {Type: schema.TypeString, Computed: true},
+						"setup_net":     {Type: schema.TypeString, Computed: true},
+						"setup_gross":   {Type: schema.TypeString, Computed: true},
+						"fixed_price":   {Type: schema.TypeBool, Computed: true},
+						"next_reduce_date": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "When the price drops next, unless fixed_price is set.",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// marketFilter holds the filter arguments of the data source. Unset filters
+// match every product.
+type marketFilter struct {
+	minRAM       int
+	cpuRegex     *regexp.Regexp
+	minDiskCount int
+	minDiskSize  int
+	datacenter   string
+	maxPrice     *float64
+	fixedPrice   *bool
+}
+
+func marketFilterFromData(d *schema.ResourceData) (marketFilter, error) {
+	f := marketFilter{
+		minRAM:       d.Get("min_ram").(int),
+		minDiskCount: d.Get("min_disk_count").(int),
+		minDiskSize:  d.Get("min_disk_size").(int),
+		datacenter:   d.Get("datacenter").(string),
+	}
+	if expr := d.Get("cpu_regex").(string); expr != "" {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return f, fmt.Errorf("invalid cpu_regex: %w", err)
+		}
+		f.cpuRegex = re
+	}
+	if v, ok := d.GetOk("max_price"); ok {
+		maxPrice := v.(float64)
+		f.maxPrice = &maxPrice
+	}
+	f.fixedPrice = optionalBool(d, "fixed_price")
+	return f, nil
+}
+
+func (f marketFilter) matches(p client.MarketProduct) bool {
+	switch {
+	case p.MemorySize < f.minRAM:
+		return false
+	case f.cpuRegex != nil && !f.cpuRegex.MatchString(p.CPU):
+		return false
+	case p.HDDCount < f.minDiskCount:
+		return false
+	case p.HDDSize < f.minDiskSize:
+		return false
+	case f.datacenter != "" && !strings.HasPrefix(p.Datacenter, f.datacenter):
+		return false
+	case f.maxPrice != nil && parsePrice(p.Price) > *f.maxPrice:
+		return false
+	case f.fixedPrice != nil && p.FixedPrice != *f.fixedPrice:
+		return false
+	}
+	return true
+}
+
+// parsePrice parses a Robot amount. Amounts that cannot be parsed compare
+// as infinitely expensive.
+func parsePrice(s string) float64 {
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return math.Inf(1)
+	}
+	return v
+}
+
+func dataSourceServerMarketRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api, ok := meta.(client.RobotAPI)
+	if !ok {
+		return diag.Errorf("invalid client type")
+	}
+	filter, err := marketFilterFromData(d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	products, err := api.Ordering().MarketProducts(ctx)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("failed to fetch server market: %w", err))
+	}
+	products = slices.DeleteFunc(products, func(p client.MarketProduct) bool { return !filter.matches(p) })
+
+	key := marketSortKeys[d.Get("sort_by").(string)]
+	descending := d.Get("sort_descending").(bool)
+	slices.SortStableFunc(products, func(a, b client.MarketProduct) int {
+		c := cmp.Compare(key(a), key(b))
+		if descending {
+			c = -c
+		}
+		if c == 0 {
+			c = cmp.Compare(a.ID, b.ID)
+		}
+		return c
+	})
+	if limit := d.Get("limit").(int); limit > 0 && len(products) > limit {
+		products = products[:limit]
+	}
+
+	productList := make([]map[string]interface{}, 0, len(products))
+	ids := make([]string, 0, len(products))
+	for _, p := range products {
+		productList = append(productList, flattenMarketProduct(p))
+		ids = append(ids, strconv.Itoa(p.ID))
+	}
+	if err := d.Set("products", productList); err != nil {
+		return diag.FromErr(err)
+	}
+	// The market changes constantly, so the ID names the products found
+	// rather than the filters.
+	d.SetId("server-market-" + strconv.Itoa(schema.HashString(strings.Join(ids, ","))))
+	return nil
+}
+
+func flattenMarketProduct(p client.MarketProduct) map[string]interface{} {
+	return map[string]interface{}{
+		"id":               p.ID,
+		"name":             p.Name,
+		"description":      p.Description,
+		"traffic":          p.Traffic,
+		"dists":            p.Dists,
+		"langs":            p.Langs,
+		"cpu":              p.CPU,
+		"cpu_benchmark":    p.CPUBenchmark,
+		"memory_size":      p.MemorySize,
+		"disk_count":       p.HDDCount,
+		"disk_size":        p.HDDSize,
+		"disk_text":        p.HDDText,
+		"datacenter":       p.Datacenter,
+		"network_speed":    p.NetworkSpeed,
+		"monthly_net":      p.Price,
+		"monthly_gross":    p.PriceVAT,
+		"setup_net":        p.PriceSetup,
+		"setup_gross":      p.PriceSetupVAT,
+		"fixed_price":      p.FixedPrice,
+		"next_reduce_date": p.NextReduceDate,
+	}
+}
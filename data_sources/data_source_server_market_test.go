@@ -0,0 +1,57 @@
+package data_sources_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/client"
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/data_sources"
+)
+
+func (s stubOrdering) MarketProducts(context.Context) ([]client.MarketProduct, error) {
+	return append([]client.MarketProduct(nil), s.market...), nil
+}
+
+func TestServerMarketDataSource(t *testing.T) {
+	api := stubOrderingAPI{ordering: stubOrdering{market: []client.MarketProduct{
+		{ID: 1, CPU: "Intel Core i7-6700", CPUBenchmark: 10000, MemorySize: 64, HDDCount: 2, HDDSize: 512, Datacenter: "FSN1-DC1", Price: "31.0000"},
+		{ID: 2, CPU: "AMD Ryzen 7 3700X", CPUBenchmark: 22000, MemorySize: 64, HDDCount: 2, HDDSize: 1024, Datacenter: "HEL1-DC2", Price: "45.0000", FixedPrice: true},
+		{ID: 3, CPU: "Intel Xeon E3-1275v6", CPUBenchmark: 9000, MemorySize: 32, HDDCount: 4, HDDSize: 4096, Datacenter: "FSN1-DC14", Price: "31.0000"},
+		{ID: 4, CPU: "AMD Ryzen 5 3600", CPUBenchmark: 17000, MemorySize: 128, HDDCount: 1, HDDSize: 512, Datacenter: "NBG1-DC3", Price: "60.0000"},
+	}}}
+
+	tests := []struct {
+		config map[string]interface{}
+		want   []int
+	}{
+		{config: map[string]interface{}{}, want: []int{1, 3, 2, 4}},
+		{config: map[string]interface{}{"min_ram": 64}, want: []int{1, 2, 4}},
+		{config: map[string]interface{}{"cpu_regex": "Ryzen"}, want: []int{2, 4}},
+		{config: map[string]interface{}{"min_disk_count": 2, "min_disk_size": 1000}, want: []int{3, 2}},
+		{config: map[string]interface{}{"datacenter": "FSN1"}, want: []int{1, 3}},
+		{config: map[string]interface{}{"max_price": 45.0}, want: []int{1, 3, 2}},
+		{config: map[string]interface{}{"fixed_price": false}, want: []int{1, 3, 4}},
+		{config: map[string]interface{}{"fixed_price": true}, want: []int{2}},
+		{config: map[string]interface{}{"sort_by": "cpu_benchmark", "sort_descending": true}, want: []int{2, 4, 1, 3}},
+		{config: map[string]interface{}{"sort_by": "disk_capacity", "sort_descending": true, "limit": 2}, want: []int{3, 2}},
+		{config: map[string]interface{}{"sort_by": "memory", "min_ram": 64}, want: []int{1, 2, 4}},
+	}
+	for _, tt := range tests {
+		r := data_sources.DataSourceServerMarket()
+		d := schema.TestResourceDataRaw(t, r.Schema, tt.config)
+		if diags := r.ReadContext(context.Background(), d, api); diags.HasError() {
+			t.Errorf("%v: %v", tt.config, diags)
+			continue
+		}
+		var got []int
+		for _, p := range d.Get("products").([]interface{}) {
+			got = append(got, p.(map[string]interface{})["id"].(int))
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("%v: products = %v, want %v", tt.config, got, tt.want)
+		}
+	}
+}
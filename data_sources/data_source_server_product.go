@@ -0,0 +1,198 @@
+package data_sources
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/client"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func DataSourceServerProducts() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceServerProductsRead,
+		Schema: map[string]*schema.Schema{
+			"product_id": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Only the product with this ID, e.g. \"AX41-NVMe\".",
+			},
+			"location": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Only products that can be ordered in this location, e.g. \"FSN1\".",
+			},
+			"max_price": {
+				Type:         schema.TypeFloat,
+				Optional:     true,
+				ValidateFunc: validation.FloatAtLeast(0),
+				Description:  "Only products whose monthly net price, in location if set and otherwise in the cheapest location, is at most this many euros.",
+			},
+			"dist": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Only products that can be installed with this distribution.",
+			},
+			"products": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id":          {Type: schema.TypeString, Computed: true},
+						"name":        {Type: schema.TypeString, Computed: true},
+						"description": {Type: schema.TypeList, Computed: true, Elem: &schema.Schema{Type: schema.TypeString}},
+						"traffic":     {Type: schema.TypeString, Computed: true},
+						"dists":       {Type: schema.TypeList, Computed: true, Elem: &schema.Schema{Type: schema.TypeString}},
+						"langs":       {Type: schema.TypeList, Computed: true, Elem: &schema.Schema{Type: schema.TypeString}},
+						"locations":   {Type: schema.TypeList, Computed: true, Elem: &schema.Schema{Type: schema.TypeString}},
+						"prices": {
+							Type:        schema.TypeList,
+							Computed:    true,
+							Description: "Prices in euros per location, as decimal strings.",
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"location":      {Type: schema.TypeString, Computed: true},
+									"monthly_net":   {Type: schema.TypeString, Computed: true},
+									"monthly_gross": {Type: schema.TypeString, Computed: true},
+									"setup_net":     {Type: schema.TypeString, Computed: true},
+									"setup_gross":   {Type: schema.TypeString, Computed: true},
+								},
+							},
+						},
+						"orderable_addons": {
+							Type:        schema.TypeList,
+							Computed:    true,
+							Description: "Addons that can be ordered with the product, by ID.",
+							Elem:        &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// productFilter holds the filter arguments of the data source. Unset
+// filters match every product.
+type productFilter struct {
+	productID string
+	location  string
+	maxPrice  *float64
+	dist      string
+}
+
+func (f productFilter) matches(p client.ServerProduct) bool {
+	switch {
+	case f.productID != "" && p.ID != f.productID:
+		return false
+	case f.location != "" && !slices.Contains(p.Locations, f.location):
+		return false
+	case f.dist != "" && !slices.Contains(p.Dists, f.dist):
+		return false
+	case f.maxPrice != nil && monthlyNetPrice(p.Prices, f.location) > *f.maxPrice:
+		return false
+	}
+	return true
+}
+
+func (f productFilter) String() string {
+	var parts []string
+	add := func(key, value string) {
+		if value != "" {
+			parts = append(parts, fmt.Sprintf("%s=%q", key, value))
+		}
+	}
+	add("product_id", f.productID)
+	add("location", f.location)
+	if f.maxPrice != nil {
+		add("max_price", strconv.FormatFloat(*f.maxPrice, 'f', -1, 64))
+	}
+	add("dist", f.dist)
+	return strings.Join(parts, ", ")
+}
+
+// monthlyNetPrice returns the monthly net price in location, or the lowest
+// one if location is empty. Prices that cannot be parsed never match.
+func monthlyNetPrice(prices []client.LocationPrice, location string) float64 {
+	lowest := math.Inf(1)
+	for _, p := range prices {
+		if location != "" && p.Location != location {
+			continue
+		}
+		if v, err := strconv.ParseFloat(p.Price.Net, 64); err == nil && v < lowest {
+			lowest = v
+		}
+	}
+	return lowest
+}
+
+func dataSourceServerProductsRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api, ok := meta.(client.RobotAPI)
+	if !ok {
+		return diag.Errorf("invalid client type")
+	}
+	filter := productFilter{
+		productID: d.Get("product_id").(string),
+		location:  d.Get("location").(string),
+		dist:      d.Get("dist").(string),
+	}
+	if v, ok := d.GetOk("max_price"); ok {
+		maxPrice := v.(float64)
+		filter.maxPrice = &maxPrice
+	}
+
+	products, err := api.Ordering().ServerProducts(ctx)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("failed to fetch server products: %w", err))
+	}
+	productList := make([]map[string]interface{}, 0, len(products))
+	for _, p := range products {
+		if filter.matches(p) {
+			productList = append(productList, flattenServerProduct(p))
+		}
+	}
+	if err := d.Set("products", productList); err != nil {
+		return diag.FromErr(err)
+	}
+
+	id := "server-products-all"
+	if f := filter.String(); f != "" {
+		id = "server-products-" + strconv.Itoa(schema.HashString(f))
+	}
+	d.SetId(id)
+	return nil
+}
+
+func flattenServerProduct(p client.ServerProduct) map[string]interface{} {
+	prices := make([]map[string]interface{}, 0, len(p.Prices))
+	for _, price := range p.Prices {
+		prices = append(prices, map[string]interface{}{
+			"location":      price.Location,
+			"monthly_net":   price.Price.Net,
+			"monthly_gross": price.Price.Gross,
+			"setup_net":     price.PriceSetup.Net,
+			"setup_gross":   price.PriceSetup.Gross,
+		})
+	}
+	addons := make([]string, 0, len(p.OrderableAddons))
+	for _, addon := range p.OrderableAddons {
+		addons = append(addons, addon.ID)
+	}
+	return map[string]interface{}{
+		"id":               p.ID,
+		"name":             p.Name,
+		"description":      p.Description,
+		"traffic":          p.Traffic,
+		"dists":            p.Dists,
+		"langs":            p.Langs,
+		"locations":        p.Locations,
+		"prices":           prices,
+		"orderable_addons": addons,
+	}
+}
@@ -0,0 +1,75 @@
+package data_sources_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/client"
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/data_sources"
+)
+
+type stubOrdering struct {
+	client.OrderingService
+	products []client.ServerProduct
+	market   []client.MarketProduct
+}
+
+func (s stubOrdering) ServerProducts(context.Context) ([]client.ServerProduct, error) {
+	return s.products, nil
+}
+
+type stubOrderingAPI struct {
+	client.RobotAPI
+	ordering stubOrdering
+}
+
+func (s stubOrderingAPI) Ordering() client.OrderingService { return s.ordering }
+
+func prices(monthly map[string]string) []client.LocationPrice {
+	var out []client.LocationPrice
+	for _, location := range []string{"FSN1", "NBG1", "HEL1"} {
+		if net, ok := monthly[location]; ok {
+			out = append(out, client.LocationPrice{Location: location, Price: client.Price{Net: net}})
+		}
+	}
+	return out
+}
+
+func TestServerProductDataSourceFilters(t *testing.T) {
+	api := stubOrderingAPI{ordering: stubOrdering{products: []client.ServerProduct{
+		{ID: "AX41-NVMe", Dists: []string{"Rescue system", "Debian 12"}, Locations: []string{"FSN1", "HEL1"}, Prices: prices(map[string]string{"FSN1": "39.0000", "HEL1": "37.0000"})},
+		{ID: "AX102", Dists: []string{"Rescue system"}, Locations: []string{"FSN1", "NBG1"}, Prices: prices(map[string]string{"FSN1": "104.0000", "NBG1": "104.0000"})},
+		{ID: "EX44", Dists: []string{"Debian 12"}, Locations: []string{"FSN1"}, Prices: prices(map[string]string{"FSN1": "44.0000"})},
+	}}}
+
+	tests := []struct {
+		config map[string]interface{}
+		want   []string
+	}{
+		{config: map[string]interface{}{}, want: []string{"AX41-NVMe", "AX102", "EX44"}},
+		{config: map[string]interface{}{"product_id": "EX44"}, want: []string{"EX44"}},
+		{config: map[string]interface{}{"location": "NBG1"}, want: []string{"AX102"}},
+		{config: map[string]interface{}{"dist": "Debian 12"}, want: []string{"AX41-NVMe", "EX44"}},
+		{config: map[string]interface{}{"max_price": 40.0}, want: []string{"AX41-NVMe"}},
+		{config: map[string]interface{}{"max_price": 38.0, "location": "FSN1"}, want: nil},
+		{config: map[string]interface{}{"max_price": 38.0, "location": "HEL1"}, want: []string{"AX41-NVMe"}},
+	}
+	for _, tt := range tests {
+		r := data_sources.DataSourceServerProducts()
+		d := schema.TestResourceDataRaw(t, r.Schema, tt.config)
+		if diags := r.ReadContext(context.Background(), d, api); diags.HasError() {
+			t.Errorf("%v: %v", tt.config, diags)
+			continue
+		}
+		var got []string
+		for _, p := range d.Get("products").([]interface{}) {
+			got = append(got, p.(map[string]interface{})["id"].(string))
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("%v: products = %v, want %v", tt.config, got, tt.want)
+		}
+	}
+}
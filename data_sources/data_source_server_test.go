@@ -0,0 +1,175 @@
+package data_sources_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/client"
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/data_sources"
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/robotfake"
+)
+
+func TestAccServerDataSource_basic(t *testing.T) {
+	fake := robotfake.New(robotfake.Options{})
+	defer fake.Close()
+	fake.AddServer(robotfake.Server{Number: 321, Name: "node-1", IP: "203.0.113.10", Datacenter: "FSN1-DC14"})
+	fake.AddServer(robotfake.Server{Number: 322, Name: "node-2", IP: "203.0.113.11"})
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fake.ProviderConfig() + `
+data "hetznerrobot_server" "all" {}
+
+data "hetznerrobot_server" "one" {
+  ids = [321]
+}
+`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.hetznerrobot_server.all", "servers.#", "2"),
+					resource.TestCheckResourceAttr("data.hetznerrobot_server.one", "servers.#", "1"),
+					resource.TestCheckResourceAttr("data.hetznerrobot_server.one", "servers.0.name", "node-1"),
+					resource.TestCheckResourceAttr("data.hetznerrobot_server.one", "servers.0.datacenter", "FSN1-DC14"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccServerDataSource_singleByName(t *testing.T) {
+	fake := robotfake.New(robotfake.Options{})
+	defer fake.Close()
+	fake.AddServer(robotfake.Server{Number: 321, Name: "node-1", IP: "203.0.113.10"})
+	fake.AddServer(robotfake.Server{Number: 322, Name: "node-2", IP: "203.0.113.11"})
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fake.ProviderConfig() + `
+data "hetznerrobot_server" "node" {
+  name   = "node-2"
+  single = true
+}
+`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.hetznerrobot_server.node", "server.0.number", "322"),
+					resource.TestCheckResourceAttr("data.hetznerrobot_server.node", "server.0.ip", "203.0.113.11"),
+				),
+			},
+		},
+	})
+}
+
+type stubAPI struct {
+	client.RobotAPI
+	servers []client.Server
+}
+
+func (s stubAPI) Servers() client.ServerService { return stubServers{servers: s.servers} }
+
+type stubServers struct {
+	client.ServerService
+	servers []client.Server
+}
+
+func (s stubServers) List(context.Context) ([]client.Server, error) {
+	return append([]client.Server(nil), s.servers...), nil
+}
+
+func TestServerDataSourceFilters(t *testing.T) {
+	api := stubAPI{servers: []client.Server{
+		{Number: 1, ServerName: "k8s-worker-1", IP: "203.0.113.1", Datacenter: "FSN1-DC14", Product: "AX41-NVMe", Status: "ready"},
+		{Number: 2, ServerName: "k8s-worker-2", IP: "203.0.113.2", IPs: []string{"203.0.113.2", "203.0.113.99"}, Datacenter: "NBG1-DC3", Product: "AX41-NVMe", Status: "ready"},
+		{Number: 3, ServerName: "db-1", IP: "203.0.113.3", Datacenter: "FSN1-DC1", Product: "AX101", Status: "ready", Cancelled: true},
+	}}
+
+	tests := []struct {
+		config  map[string]interface{}
+		want    []int
+		wantErr string
+	}{
+		{config: map[string]interface{}{}, want: []int{1, 2, 3}},
+		{config: map[string]interface{}{"name": "db-1"}, want: []int{3}},
+		{config: map[string]interface{}{"name_regex": "^k8s-worker-"}, want: []int{1, 2}},
+		{config: map[string]interface{}{"ip": "203.0.113.99"}, want: []int{2}},
+		{config: map[string]interface{}{"datacenter": "FSN1"}, want: []int{1, 3}},
+		{config: map[string]interface{}{"product": "AX41-NVMe", "datacenter": "NBG1"}, want: []int{2}},
+		{config: map[string]interface{}{"cancelled": false}, want: []int{1, 2}},
+		{config: map[string]interface{}{"cancelled": true}, want: []int{3}},
+		{config: map[string]interface{}{"name": "db-1", "single": true}, want: []int{3}},
+		{config: map[string]interface{}{"name_regex": "^k8s", "single": true}, wantErr: "found 2"},
+		{config: map[string]interface{}{"name": "missing", "single": true}, wantErr: "found 0"},
+	}
+	for _, tt := range tests {
+		r := data_sources.DataSourceServers()
+		d := schema.TestResourceDataRaw(t, r.Schema, tt.config)
+		diags := r.ReadContext(context.Background(), d, api)
+		if tt.wantErr != "" {
+			if !diags.HasError() || !strings.Contains(diags[0].Summary, tt.wantErr) {
+				t.Errorf("%v: diags = %v, want error %q", tt.config, diags, tt.wantErr)
+			}
+			continue
+		}
+		if diags.HasError() {
+			t.Errorf("%v: %v", tt.config, diags)
+			continue
+		}
+		var got []int
+		for _, s := range d.Get("servers").([]interface{}) {
+			got = append(got, s.(map[string]interface{})["number"].(int))
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("%v: servers = %v, want %v", tt.config, got, tt.want)
+		}
+		if single, _ := tt.config["single"].(bool); single && d.Get("server.0.number").(int) != tt.want[0] {
+			t.Errorf("%v: server.0.number = %v", tt.config, d.Get("server.0.number"))
+		}
+	}
+}
+
+func (s stubServers) Fetch(_ context.Context, number int) (*client.Server, error) {
+	for _, srv := range s.servers {
+		if srv.Number == number {
+			srv.Reset, srv.WOL = true, true
+			return &srv, nil
+		}
+	}
+	return nil, client.ErrNotFound
+}
+
+func TestServerDataSourceDetails(t *testing.T) {
+	api := stubAPI{servers: []client.Server{{
+		Number:     1,
+		ServerName: "node-1",
+		IP:         "203.0.113.1",
+		IPs:        []string{"203.0.113.1"},
+		Subnets:    []client.ServerSubnet{{IP: "2001:db8:1::", Mask: "64"}},
+	}}}
+	r := data_sources.DataSourceServers()
+
+	d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{"single": true})
+	if diags := r.ReadContext(context.Background(), d, api); diags.HasError() {
+		t.Fatal(diags)
+	}
+	if got := d.Get("server.0.subnets.0.ip"); got != "2001:db8:1::" {
+		t.Errorf("subnets.0.ip = %v", got)
+	}
+	if !d.Get("server.0.capabilities.0.wol").(bool) || d.Get("server.0.capabilities.0.vnc").(bool) {
+		t.Errorf("capabilities = %v, want wol only", d.Get("server.0.capabilities"))
+	}
+
+	d = schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{"single": true, "include_details": false})
+	if diags := r.ReadContext(context.Background(), d, api); diags.HasError() {
+		t.Fatal(diags)
+	}
+	if n := d.Get("server.0.capabilities.#"); n != 0 {
+		t.Errorf("capabilities set with include_details = false: %v", n)
+	}
+}
@@ -6,9 +6,9 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/client"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
-	"hcloud-robot-provider/client"
 )
 
 func DataSourceVSwitches() *schema.Resource {
@@ -41,26 +41,32 @@ func DataSourceVSwitches() *schema.Resource {
 }
 
 func dataSourceVSwitchesRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	hClient, ok := meta.(*client.HetznerRobotClient)
+	api, ok := meta.(client.RobotAPI)
 	if !ok {
-		return diag.Errorf("meta is not of type *client.HetznerRobotClient")
+		return diag.Errorf("meta is not of type client.RobotAPI")
 	}
 	idsInterface := d.Get("ids").([]interface{})
 	var ids []string
+	var numericIDs []int
 	for _, id := range idsInterface {
+		numericID, err := strconv.Atoi(id.(string))
+		if err != nil {
+			return diag.Errorf("invalid vSwitch ID %q: must be a number", id)
+		}
 		ids = append(ids, id.(string))
+		numericIDs = append(numericIDs, numericID)
 	}
 	var (
 		vswitches []client.VSwitch
 		err       error
 	)
 	if len(ids) == 0 {
-		vswitches, err = hClient.FetchAllVSwitches(ctx)
+		vswitches, err = api.VSwitches().List(ctx)
 		if err != nil {
 			return diag.FromErr(fmt.Errorf("error fetching ALL vSwitches: %w", err))
 		}
 	} else {
-		vswitches, err = hClient.FetchVSwitchesByIDs(ids)
+		vswitches, err = api.VSwitches().GetMany(ctx, numericIDs)
 		if err != nil {
 			return diag.FromErr(fmt.Errorf("error fetching vSwitches by IDs: %w", err))
 		}
@@ -1,12 +1,15 @@
 package main
 
 import (
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/provider"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/plugin"
-	"hcloud-robot-provider/provider"
 )
 
+// version is set by the release build via -ldflags "-X main.version=...".
+var version = "dev"
+
 func main() {
 	plugin.Serve(&plugin.ServeOpts{
-		ProviderFunc: provider.Provider,
+		ProviderFunc: provider.New(version),
 	})
 }
@@ -0,0 +1,259 @@
+package provider
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+const (
+	envUsername          = "HETZNERROBOT_USERNAME"
+	envPassword          = "HETZNERROBOT_PASSWORD"
+	envCredentialsFile   = "HETZNERROBOT_CREDENTIALS_FILE"
+	envProfile           = "HETZNERROBOT_PROFILE"
+	envCredentialProcess = "HETZNERROBOT_CREDENTIAL_PROCESS"
+
+	defaultProfile           = "default"
+	credentialProcessTimeout = time.Minute
+)
+
+// credentialSettings holds everything providerConfigure knows about where the
+// Robot credentials may come from.
+type credentialSettings struct {
+	// Username and Password are set from the provider block or, failing
+	// that, from the environment; the matching *Source names which.
+	Username       string
+	UsernameSource string
+	Password       string
+	PasswordSource string
+
+	CredentialsFile   string
+	Profile           string
+	CredentialProcess string
+}
+
+type credentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// credentialSettingsFromData reads the credential related provider settings.
+func credentialSettingsFromData(d *schema.ResourceData) credentialSettings {
+	return credentialSettings{
+		Username:          d.Get("username").(string),
+		UsernameSource:    attributeSource(d, "username", envUsername),
+		Password:          d.Get("password").(string),
+		PasswordSource:    attributeSource(d, "password", envPassword),
+		CredentialsFile:   d.Get("credentials_file").(string),
+		Profile:           d.Get("profile").(string),
+		CredentialProcess: d.Get("credential_process").(string),
+	}
+}
+
+// attributeSource describes where the value of an attribute with an
+// environment variable default came from.
+func attributeSource(d *schema.ResourceData, key, env string) string {
+	raw := d.GetRawConfig()
+	if !raw.IsNull() && raw.IsKnown() && !raw.GetAttr(key).IsNull() {
+		return "provider configuration"
+	}
+	if os.Getenv(env) != "" {
+		return "environment variable " + env
+	}
+	return "provider configuration"
+}
+
+// resolveCredentials picks the Robot credentials from the first complete
+// source, in order: username and password (from the provider block or the
+// environment), credential_process, credentials_file. It returns a
+// description of the source that was used.
+func resolveCredentials(ctx context.Context, s credentialSettings) (credentials, string, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	if s.Username != "" && s.Password != "" {
+		source := s.UsernameSource
+		if s.PasswordSource != s.UsernameSource {
+			source = fmt.Sprintf("username from %s, password from %s", s.UsernameSource, s.PasswordSource)
+		}
+		return credentials{Username: s.Username, Password: s.Password}, source, nil
+	}
+
+	var partial string
+	switch {
+	case s.Username != "":
+		partial = fmt.Sprintf("username is set in %s but password is not set", s.UsernameSource)
+	case s.Password != "":
+		partial = fmt.Sprintf("password is set in %s but username is not set", s.PasswordSource)
+	}
+	if partial != "" && (s.CredentialProcess != "" || s.CredentialsFile != "") {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary:  "Incomplete credentials ignored",
+			Detail:   partial + ", so the credentials are taken from another source instead.",
+		})
+	}
+
+	if s.CredentialProcess != "" {
+		creds, err := runCredentialProcess(ctx, s.CredentialProcess)
+		if err != nil {
+			return credentials{}, "", append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Failed to obtain credentials from credential_process",
+				Detail:   err.Error(),
+			})
+		}
+		return creds, "credential_process", diags
+	}
+
+	if s.CredentialsFile != "" {
+		profile := s.Profile
+		if profile == "" {
+			profile = defaultProfile
+		}
+		creds, err := readCredentialsFile(s.CredentialsFile, profile)
+		if err != nil {
+			return credentials{}, "", append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Failed to read credentials_file",
+				Detail:   err.Error(),
+			})
+		}
+		return creds, fmt.Sprintf("credentials_file %s (profile %q)", s.CredentialsFile, profile), diags
+	}
+
+	detail := fmt.Sprintf("Set username and password in the provider block, export %s and %s, or configure credential_process or credentials_file.", envUsername, envPassword)
+	if partial != "" {
+		detail = "The " + partial + ". " + detail
+	}
+	return credentials{}, "", append(diags, diag.Diagnostic{
+		Severity: diag.Error,
+		Summary:  "Missing credentials",
+		Detail:   detail,
+	})
+}
+
+// runCredentialProcess runs command through the shell and parses its standard
+// output as a JSON object with username and password.
+func runCredentialProcess(ctx context.Context, command string) (credentials, error) {
+	ctx, cancel := context.WithTimeout(ctx, credentialProcessTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "/bin/sh", "-c", command)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	tflog.Debug(ctx, "Running credential_process", map[string]interface{}{"command": command})
+	if err := cmd.Run(); err != nil {
+		return credentials{}, fmt.Errorf("%q failed: %w: %s", command, err, strings.TrimSpace(stderr.String()))
+	}
+
+	var creds credentials
+	if err := json.Unmarshal(stdout.Bytes(), &creds); err != nil {
+		return credentials{}, fmt.Errorf("%q did not print a JSON object with username and password: %w", command, err)
+	}
+	if creds.Username == "" || creds.Password == "" {
+		return credentials{}, fmt.Errorf("%q printed no username or no password", command)
+	}
+	return creds, nil
+}
+
+// readCredentialsFile reads a profile from a credentials file. The file is
+// either JSON, an object of profiles:
+//
+//	{"default": {"username": "...", "password": "..."}}
+//
+// or INI, with one section per profile:
+//
+//	[default]
+//	username = ...
+//	password = ...
+func readCredentialsFile(path, profile string) (credentials, error) {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return credentials{}, err
+		}
+		path = filepath.Join(home, rest)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return credentials{}, err
+	}
+
+	var profiles map[string]credentials
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		if err := json.Unmarshal(trimmed, &profiles); err != nil {
+			return credentials{}, fmt.Errorf("invalid JSON in %s: %w", path, err)
+		}
+	} else if profiles, err = parseINICredentials(data); err != nil {
+		return credentials{}, fmt.Errorf("invalid INI in %s: %w", path, err)
+	}
+
+	creds, ok := profiles[profile]
+	if !ok {
+		return credentials{}, fmt.Errorf("profile %q not found in %s", profile, path)
+	}
+	if creds.Username == "" || creds.Password == "" {
+		return credentials{}, fmt.Errorf("profile %q in %s has no username or no password", profile, path)
+	}
+	return creds, nil
+}
+
+func parseINICredentials(data []byte) (map[string]credentials, error) {
+	profiles := make(map[string]credentials)
+	section := ""
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("line %d: unterminated section header", n)
+			}
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			if _, ok := profiles[section]; !ok {
+				profiles[section] = credentials{}
+			}
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key = value", n)
+		}
+		if section == "" {
+			return nil, fmt.Errorf("line %d: key outside of a [profile] section", n)
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		creds := profiles[section]
+		switch key {
+		case "username":
+			creds.Username = value
+		case "password":
+			creds.Password = value
+		}
+		profiles[section] = creds
+	}
+	return profiles, scanner.Err()
+}
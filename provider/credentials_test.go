@@ -0,0 +1,121 @@
+package provider
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadCredentialsFile(t *testing.T) {
+	ini := writeFile(t, "credentials", `
+# Robot webservice users
+[default]
+username = robot-user
+password = "s3cret=="
+
+[staging]
+username=staging-user
+password=staging-pass
+`)
+	json := writeFile(t, "credentials.json", `{
+  "default": {"username": "robot-user", "password": "s3cret=="},
+  "staging": {"username": "staging-user", "password": "staging-pass"}
+}`)
+
+	for _, path := range []string{ini, json} {
+		for profile, want := range map[string]credentials{
+			"default": {Username: "robot-user", Password: "s3cret=="},
+			"staging": {Username: "staging-user", Password: "staging-pass"},
+		} {
+			got, err := readCredentialsFile(path, profile)
+			if err != nil {
+				t.Fatalf("%s [%s]: %v", filepath.Base(path), profile, err)
+			}
+			if got != want {
+				t.Errorf("%s [%s] = %+v, want %+v", filepath.Base(path), profile, got, want)
+			}
+		}
+		if _, err := readCredentialsFile(path, "missing"); err == nil || !strings.Contains(err.Error(), `profile "missing" not found`) {
+			t.Errorf("%s: missing profile error = %v", filepath.Base(path), err)
+		}
+	}
+}
+
+func TestReadCredentialsFileRejectsIncompleteProfile(t *testing.T) {
+	path := writeFile(t, "credentials", "[default]\nusername = robot-user\n")
+	if _, err := readCredentialsFile(path, "default"); err == nil {
+		t.Fatal("accepted a profile without password")
+	}
+}
+
+func TestResolveCredentialsPrecedence(t *testing.T) {
+	file := writeFile(t, "credentials", "[default]\nusername = file-user\npassword = file-pass\n")
+	ctx := context.Background()
+
+	creds, source, diags := resolveCredentials(ctx, credentialSettings{
+		Username: "block-user", UsernameSource: "provider configuration",
+		Password: "env-pass", PasswordSource: "environment variable " + envPassword,
+		CredentialsFile: file,
+	})
+	if diags.HasError() || creds.Username != "block-user" || creds.Password != "env-pass" {
+		t.Fatalf("static credentials: %+v %v", creds, diags)
+	}
+	if source != "username from provider configuration, password from environment variable HETZNERROBOT_PASSWORD" {
+		t.Errorf("source = %q", source)
+	}
+
+	creds, source, diags = resolveCredentials(ctx, credentialSettings{
+		Username: "env-user", UsernameSource: "environment variable " + envUsername,
+		CredentialsFile: file,
+	})
+	if diags.HasError() || creds.Username != "file-user" {
+		t.Fatalf("file credentials: %+v %v", creds, diags)
+	}
+	if len(diags) != 1 || !strings.Contains(diags[0].Detail, "password is not set") {
+		t.Errorf("want a warning about the ignored username, got %v", diags)
+	}
+	if !strings.Contains(source, "credentials_file") || !strings.Contains(source, `profile "default"`) {
+		t.Errorf("source = %q", source)
+	}
+
+	_, _, diags = resolveCredentials(ctx, credentialSettings{Password: "p", PasswordSource: "provider configuration"})
+	if !diags.HasError() || !strings.Contains(diags[0].Detail, "username is not set") {
+		t.Errorf("want missing credentials error naming the username, got %v", diags)
+	}
+}
+
+func TestResolveCredentialsFromProcess(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+	ctx := context.Background()
+	file := writeFile(t, "credentials", "[default]\nusername = file-user\npassword = file-pass\n")
+
+	creds, source, diags := resolveCredentials(ctx, credentialSettings{
+		CredentialProcess: `printf '{"username":"%s","password":"proc-pass"}' proc-user`,
+		CredentialsFile:   file,
+	})
+	if diags.HasError() {
+		t.Fatalf("credential_process: %v", diags)
+	}
+	if creds != (credentials{Username: "proc-user", Password: "proc-pass"}) || source != "credential_process" {
+		t.Errorf("got %+v from %q", creds, source)
+	}
+
+	_, _, diags = resolveCredentials(ctx, credentialSettings{CredentialProcess: "echo vault sealed >&2; exit 3"})
+	if !diags.HasError() || !strings.Contains(diags[0].Detail, "vault sealed") {
+		t.Errorf("want failure with stderr, got %v", diags)
+	}
+}
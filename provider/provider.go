@@ -2,29 +2,64 @@ package provider
 
 import (
 	"context"
-	"hcloud-robot-provider/data_sources"
-	"hcloud-robot-provider/resources"
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/data_sources"
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/resources"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 
-	"hcloud-robot-provider/client"
-	"hcloud-robot-provider/shared"
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/client"
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/shared"
 )
 
-func Provider() *schema.Provider {
+// New returns a factory for the provider. version is the provider release
+// and is reported in the User-Agent of Robot API requests.
+func New(version string) func() *schema.Provider {
+	return func() *schema.Provider {
+		p := providerSchema()
+		p.ConfigureContextFunc = func(ctx context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
+			return providerConfigure(ctx, d, p.UserAgent("terraform-provider-hetznerrobot", version))
+		}
+		return p
+	}
+}
+
+func providerSchema() *schema.Provider {
 	return &schema.Provider{
 		Schema: map[string]*schema.Schema{
 			"username": {
 				Type:        schema.TypeString,
-				Required:    true,
-				Description: "Hetzner Robot API username.",
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc(envUsername, nil),
+				Description: "Hetzner Robot API username. Can also be set with the HETZNERROBOT_USERNAME environment variable.",
 			},
 			"password": {
 				Type:        schema.TypeString,
-				Required:    true,
+				Optional:    true,
 				Sensitive:   true,
-				Description: "Hetzner Robot API password.",
+				DefaultFunc: schema.EnvDefaultFunc(envPassword, nil),
+				Description: "Hetzner Robot API password. Can also be set with the HETZNERROBOT_PASSWORD environment variable.",
+			},
+			"credentials_file": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc(envCredentialsFile, nil),
+				Description: "Path to an INI or JSON file with named profiles holding username and password. Used when username and password are not set.",
+			},
+			"profile": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc(envProfile, defaultProfile),
+				Description: "Profile of credentials_file to use.",
+			},
+			"credential_process": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc(envCredentialProcess, nil),
+				Description: "Command that prints a JSON object with username and password to standard output. Used when username and password are not set; takes precedence over credentials_file.",
 			},
 			"url": {
 				Type:        schema.TypeString,
@@ -32,38 +67,201 @@ func Provider() *schema.Provider {
 				DefaultFunc: schema.EnvDefaultFunc("HETZNERROBOT_URL", "https://robot-ws.your-server.de"),
 				Description: "Base URL for the Hetzner Robot API.",
 			},
+			"read_only": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("HETZNERROBOT_READ_ONLY", false),
+				Description: "Reject every mutating Robot API call and installer run before it is sent, so that plans with production credentials can never change anything. Can also be set with the HETZNERROBOT_READ_ONLY environment variable.",
+			},
+			"audit_log_file": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("HETZNERROBOT_AUDIT_LOG", nil),
+				Description: "Path of a file to which one JSON line is appended for every mutating Robot API request (time, users, resource, method, path, redacted form, response status and Robot error code). Can also be set with the HETZNERROBOT_AUDIT_LOG environment variable.",
+			},
+			"max_retries": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("HETZNERROBOT_MAX_RETRIES", 5),
+				Description: "Maximum number of retries for rate-limited, maintenance or transient failures of Robot API requests. Set to 0 to disable retries.",
+			},
+			"retry_wait_min": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      1,
+				ValidateFunc: validation.IntAtLeast(1),
+				Description:  "Minimum time in seconds to wait between retries. Must be at least 1.",
+			},
+			"retry_wait_max": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Default:     30,
+				Description: "Maximum time in seconds to wait between retries.",
+			},
+			"rate_limit": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Description: "Overrides the client-side request budget of an endpoint family. Requests beyond the budget are delayed instead of being sent.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"family": {
+							Type:        schema.TypeString,
+							Required:    true,
+							Description: "Endpoint family, i.e. the first path segment of the Robot API (server, reset, boot, firewall, vswitch, order, ...), or \"default\" for all families without an explicit budget.",
+						},
+						"requests_per_hour": {
+							Type:        schema.TypeInt,
+							Required:    true,
+							Description: "Sustained number of requests per hour. Set to 0 to disable throttling for the family.",
+						},
+						"burst": {
+							Type:        schema.TypeInt,
+							Optional:    true,
+							Default:     1,
+							Description: "Number of requests that may be sent at once before throttling starts.",
+						},
+					},
+				},
+			},
+			"request_timeout": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("HETZNERROBOT_REQUEST_TIMEOUT", 60),
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "Timeout in seconds of a single Robot API request, including reading the response. Retries get a fresh timeout. Set to 0 to disable.",
+			},
+			"max_idle_connections": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      10,
+				ValidateFunc: validation.IntAtLeast(1),
+				Description:  "Maximum number of idle keep-alive connections to the Robot API.",
+			},
+			"idle_connection_timeout": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      90,
+				ValidateFunc: validation.IntAtLeast(1),
+				Description:  "Time in seconds after which an idle keep-alive connection is closed.",
+			},
+			"proxy_url": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("HETZNERROBOT_PROXY_URL", nil),
+				ValidateFunc: validation.IsURLWithScheme([]string{"http", "https", "socks5"}),
+				Description:  "URL of the HTTP(S) or SOCKS5 proxy for Robot API requests. Defaults to the proxy from the HTTPS_PROXY and NO_PROXY environment variables.",
+			},
+			"ca_file": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("HETZNERROBOT_CA_FILE", nil),
+				Description: "Path to a PEM bundle of CA certificates trusted in addition to the system roots, e.g. for a TLS-inspecting gateway.",
+			},
+			"client_cert_file": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				RequiredWith: []string{"client_key_file"},
+				Description:  "Path to a PEM client certificate presented to the Robot API or proxy.",
+			},
+			"client_key_file": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				RequiredWith: []string{"client_cert_file"},
+				Description:  "Path to the PEM private key of client_cert_file.",
+			},
+			"rescue_ssh_port": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      22,
+				ValidateFunc: validation.IsPortNumber,
+				Description:  "Port of the SSH server of the rescue system used by hetznerrobot_os_install. Only needs changing when the rescue system is reached through a port forward.",
+			},
 		},
 		ResourcesMap: map[string]*schema.Resource{
-			"hetznerrobot_vswitch":    resources.ResourceVSwitch(),
-			"hetznerrobot_firewall":   resources.ResourceFirewall(),
-			"hetznerrobot_os_install": resources.ResourceBootInstaller(),
+			"hetznerrobot_vswitch":             resources.ResourceVSwitch(),
+			"hetznerrobot_firewall":            resources.ResourceFirewall(),
+			"hetznerrobot_os_install":          resources.ResourceBootInstaller(),
+			"hetznerrobot_server":              resources.ResourceServer(),
+			"hetznerrobot_server_order":        resources.ResourceServerOrder(),
+			"hetznerrobot_server_market_order": resources.ResourceServerMarketOrder(),
+			"hetznerrobot_server_addon_order":  resources.ResourceServerAddonOrder(),
 		},
 		DataSourcesMap: map[string]*schema.Resource{
-			"hetznerrobot_server":  data_sources.DataSourceServers(),
-			"hetznerrobot_vswitch": data_sources.DataSourceVSwitches(),
+			"hetznerrobot_server":         data_sources.DataSourceServers(),
+			"hetznerrobot_vswitch":        data_sources.DataSourceVSwitches(),
+			"hetznerrobot_server_product": data_sources.DataSourceServerProducts(),
+			"hetznerrobot_server_market":  data_sources.DataSourceServerMarket(),
+			"hetznerrobot_server_addon":   data_sources.DataSourceServerAddons(),
 		},
-		ConfigureContextFunc: providerConfigure,
 	}
 }
 
-func providerConfigure(ctx context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
+func providerConfigure(ctx context.Context, d *schema.ResourceData, userAgent string) (interface{}, diag.Diagnostics) {
 	var diags diag.Diagnostics
-	username := d.Get("username").(string)
-	password := d.Get("password").(string)
 	url := d.Get("url").(string)
-	if username == "" || password == "" {
+	maxRetries := d.Get("max_retries").(int)
+	retryWaitMin := d.Get("retry_wait_min").(int)
+	retryWaitMax := d.Get("retry_wait_max").(int)
+	creds, source, credDiags := resolveCredentials(ctx, credentialSettingsFromData(d))
+	diags = append(diags, credDiags...)
+	if diags.HasError() {
+		return nil, diags
+	}
+	tflog.Info(ctx, "Using Robot credentials", map[string]interface{}{
+		"source":   source,
+		"username": creds.Username,
+	})
+	if d.Get("read_only").(bool) {
+		tflog.Info(ctx, "Read-only mode enabled, mutating Robot API calls will be rejected")
+	}
+	if maxRetries < 0 || retryWaitMin < 1 || retryWaitMax < retryWaitMin {
 		diags = append(diags, diag.Diagnostic{
 			Severity: diag.Error,
-			Summary:  "Missing credentials",
-			Detail:   "Both username and password must be provided.",
+			Summary:  "Invalid retry settings",
+			Detail:   "max_retries must not be negative, retry_wait_min must be at least 1, and retry_wait_max must not be lower than retry_wait_min.",
 		})
 		return nil, diags
 	}
+	rateLimits := make(map[string]shared.RateLimit)
+	for _, raw := range d.Get("rate_limit").([]interface{}) {
+		m := raw.(map[string]interface{})
+		rateLimits[m["family"].(string)] = shared.RateLimit{
+			RequestsPerHour: m["requests_per_hour"].(int),
+			Burst:           m["burst"].(int),
+		}
+	}
 	config := &shared.ProviderConfig{
-		Username: username,
-		Password: password,
+		Username: creds.Username,
+		Password: creds.Password,
 		BaseURL:  url,
+		ReadOnly: d.Get("read_only").(bool),
+
+		AuditLogFile: d.Get("audit_log_file").(string),
+
+		MaxRetries:   maxRetries,
+		RetryWaitMin: time.Duration(retryWaitMin) * time.Second,
+		RetryWaitMax: time.Duration(retryWaitMax) * time.Second,
+		RateLimits:   rateLimits,
+
+		RequestTimeout:  time.Duration(d.Get("request_timeout").(int)) * time.Second,
+		MaxIdleConns:    d.Get("max_idle_connections").(int),
+		IdleConnTimeout: time.Duration(d.Get("idle_connection_timeout").(int)) * time.Second,
+		ProxyURL:        d.Get("proxy_url").(string),
+		CACertFile:      d.Get("ca_file").(string),
+		ClientCertFile:  d.Get("client_cert_file").(string),
+		ClientKeyFile:   d.Get("client_key_file").(string),
+		UserAgent:       userAgent,
+
+		RescueSSHPort: d.Get("rescue_ssh_port").(int),
+	}
+	client, err := client.NewHetznerRobotClient(config)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Invalid HTTP transport settings",
+			Detail:   err.Error(),
+		})
+		return nil, diags
 	}
-	client := client.NewHetznerRobotClient(config)
 	return client, diags
 }
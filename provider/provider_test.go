@@ -0,0 +1,43 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/client"
+)
+
+func TestProvider(t *testing.T) {
+	if err := New("test")().InternalValidate(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+}
+
+func TestProviderReadOnlyFromEnvironment(t *testing.T) {
+	t.Setenv("HETZNERROBOT_READ_ONLY", "true")
+	p := New("test")()
+	diags := p.Configure(context.Background(), terraform.NewResourceConfigRaw(map[string]interface{}{
+		"username": "robot-user",
+		"password": "robot-password",
+	}))
+	if diags.HasError() {
+		t.Fatalf("Configure: %v", diags)
+	}
+	if !p.Meta().(*client.HetznerRobotClient).Config.ReadOnly {
+		t.Error("HETZNERROBOT_READ_ONLY did not enable read-only mode")
+	}
+}
+
+func TestProviderRejectsZeroRetryWaitMin(t *testing.T) {
+	p := New("test")()
+	diags := p.Configure(context.Background(), terraform.NewResourceConfigRaw(map[string]interface{}{
+		"username":       "robot-user",
+		"password":       "robot-password",
+		"retry_wait_min": 0,
+	}))
+	if !diags.HasError() || diags[0].Summary != "Invalid retry settings" {
+		t.Fatalf("Configure diags = %v, want invalid retry settings", diags)
+	}
+}
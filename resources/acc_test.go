@@ -0,0 +1,13 @@
+package resources_test
+
+import (
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/provider"
+)
+
+var testAccProviderFactories = map[string]func() (*schema.Provider, error){
+	"hetznerrobot": func() (*schema.Provider, error) {
+		return provider.New("test")(), nil
+	},
+}
@@ -0,0 +1,95 @@
+package resources
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/client"
+)
+
+// orderTransactions follows the transactions of an order resource, whose ID
+// is the transaction ID, from being placed until what was ordered has been
+// assigned.
+//
+// An order is paid for as soon as it is placed, so the resource must not be
+// dropped from state unless the order was cancelled before anything was
+// assigned: that would order again on the next apply. Robot does not keep
+// test transactions, and purges the others about 30 days after the order,
+// so a missing transaction keeps the stored state.
+type orderTransactions[T any] struct {
+	// what is ordered, e.g. "Server", for messages. assignedAttrs names the
+	// attributes filled in once it has been assigned.
+	what          string
+	assignedAttrs string
+
+	get    func(ordering client.OrderingService) func(ctx context.Context, id string) (T, error)
+	wait   func(ordering client.OrderingService) func(ctx context.Context, id string, timeout time.Duration) (T, error)
+	status func(tx T) string
+	// isAssigned reports whether what was ordered has been assigned.
+	isAssigned func(tx T) bool
+	set        func(d *schema.ResourceData, tx T)
+}
+
+// placed finishes creating the resource of the order tx, which d already
+// has the ID of, by waiting for it to be assigned.
+func (o orderTransactions[T]) placed(ctx context.Context, d *schema.ResourceData, m interface{}, tx T) diag.Diagnostics {
+	if d.Get("test").(bool) {
+		tflog.Info(ctx, "Test order accepted", map[string]interface{}{"transaction_id": d.Id()})
+		o.set(d, tx)
+		return nil
+	}
+	api := m.(client.RobotAPI)
+	_, err := o.wait(api.Ordering())(ctx, d.Id(), d.Timeout(schema.TimeoutCreate))
+	switch {
+	case client.IsOperationFailed(err):
+		d.SetId("")
+		return diag.FromErr(err)
+	case err != nil:
+		// Failing would taint the resource and order again on the next
+		// apply. Read fills in the rest once it has been assigned.
+		o.set(d, tx)
+		return diag.Diagnostics{{
+			Severity: diag.Warning,
+			Summary:  o.what + " order is still in process",
+			Detail:   fmt.Sprintf("Transaction %s was placed, but the %s was not assigned in time: %v. Refreshes fill in %s once it has been assigned.", d.Id(), strings.ToLower(o.what), err, o.assignedAttrs),
+		}}
+	}
+	return o.read(ctx, d, m)
+}
+
+// read refreshes the resource from the current state of the order without
+// waiting for it: orders with a comment are handled by hand and can stay in
+// process for days.
+func (o orderTransactions[T]) read(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	if d.Get("test").(bool) {
+		return nil
+	}
+	api := m.(client.RobotAPI)
+	tx, err := o.get(api.Ordering())(ctx, d.Id())
+	switch {
+	case client.IsNotFound(err) && d.Get("status").(string) == "ready":
+		tflog.Warn(ctx, o.what+" order transaction no longer exists, keeping stored state", map[string]interface{}{"transaction_id": d.Id()})
+		return nil
+	case client.IsNotFound(err):
+		return diag.Diagnostics{{
+			Severity: diag.Warning,
+			Summary:  o.what + " order transaction no longer exists",
+			Detail:   fmt.Sprintf("Robot no longer has transaction %s, which was %q when last read, so %s will not be filled in. Check the order in Robot.", d.Id(), d.Get("status"), o.assignedAttrs),
+		}}
+	case err != nil:
+		return diag.FromErr(err)
+	}
+	if o.status(tx) == "cancelled" && !o.isAssigned(tx) {
+		tflog.Warn(ctx, o.what+" order was cancelled, removing from state", map[string]interface{}{"transaction_id": d.Id()})
+		d.SetId("")
+		return nil
+	}
+	o.set(d, tx)
+	return nil
+}
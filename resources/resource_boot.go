@@ -2,16 +2,24 @@ package resources
 
 import (
 	"context"
-	"fmt"
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/client"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
-	"hcloud-robot-provider/client"
-	"net"
 	"strconv"
 	"sync"
 	"time"
 )
 
+// Delays of the installation flow. They are variables so that tests running
+// against robotfake do not have to wait for real hardware. The flow as a
+// whole, including waiting for SSH, is bounded by the timeouts of the
+// resource.
+var (
+	installerSettleTime = 30 * time.Second
+	sshPollInterval     = 10 * time.Second
+)
+
 type ServerInput struct {
 	ID   string
 	Name string
@@ -35,6 +43,11 @@ func ResourceBootInstaller() *schema.Resource {
 		ReadContext:   schema.NoopContext,
 		UpdateContext: resourceBootInstallerUpdate,
 		DeleteContext: resourceBootInstallerDelete,
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(30 * time.Minute),
+			Update: schema.DefaultTimeout(5 * time.Minute),
+			Delete: schema.DefaultTimeout(5 * time.Minute),
+		},
 		Schema: map[string]*schema.Schema{
 			"servers": {
 				Type:        schema.TypeList,
@@ -99,6 +112,12 @@ func ResourceBootInstaller() *schema.Resource {
 							Type:     schema.TypeString,
 							Computed: true,
 						},
+						"rescue_password": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Sensitive:   true,
+							Description: "Root password of the rescue system used for the installation.",
+						},
 					},
 				},
 			},
@@ -107,7 +126,8 @@ func ResourceBootInstaller() *schema.Resource {
 }
 
 func resourceBootInstallerCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	cfg := meta.(*client.HetznerRobotClient)
+	api := meta.(client.RobotAPI)
+	ctx = client.WithAuditResource(ctx, "hetznerrobot_os_install", "")
 	rawServers := d.Get("servers").([]interface{})
 	servers := expandServerList(rawServers)
 	rescueOS := d.Get("rescue_os").(string)
@@ -116,6 +136,10 @@ func resourceBootInstallerCreate(ctx context.Context, d *schema.ResourceData, me
 	for _, key := range sshKeysRaw {
 		sshKeys = append(sshKeys, key.(string))
 	}
+	createTimeout := d.Timeout(schema.TimeoutCreate)
+	if left := client.TimeLeft(ctx, createTimeout); left < installerSettleTime {
+		return diag.Errorf("the create timeout leaves %v, less than the %v servers need to settle after their reset", left.Round(time.Second), installerSettleTime)
+	}
 	var (
 		wg      sync.WaitGroup
 		mu      sync.Mutex
@@ -135,23 +159,30 @@ func resourceBootInstallerCreate(ctx context.Context, d *schema.ResourceData, me
 				return
 			}
 
-			if err := cfg.DisableRescueMode(ctx, serverID); err != nil {
+			if err := api.Boot().DisableRescue(ctx, serverID); err != nil {
 				mu.Lock()
 				diags = append(diags, diag.Errorf("failed to disable rescue mode for server %d: %v", serverID, err)...)
 				mu.Unlock()
 				return
 			}
 
-			if err := cfg.RebootServer(ctx, serverID, "hw"); err != nil {
+			if err := api.Reset().Reboot(ctx, serverID, "hw"); err != nil {
 				mu.Lock()
 				diags = append(diags, diag.Errorf("failed to reboot server %d: %v", serverID, err)...)
 				mu.Unlock()
 				return
 			}
 
-			time.Sleep(30 * time.Second)
+			select {
+			case <-ctx.Done():
+				mu.Lock()
+				diags = append(diags, diag.FromErr(ctx.Err())...)
+				mu.Unlock()
+				return
+			case <-time.After(installerSettleTime):
+			}
 
-			_, err = cfg.RenameServer(ctx, serverID, srv.Name)
+			_, err = api.Servers().Rename(ctx, serverID, srv.Name)
 			if err != nil {
 				mu.Lock()
 				diags = append(diags, diag.Errorf("failed to rename server %d: %v", serverID, err)...)
@@ -159,7 +190,7 @@ func resourceBootInstallerCreate(ctx context.Context, d *schema.ResourceData, me
 				return
 			}
 
-			rescueResp, err := cfg.EnableRescueMode(ctx, serverID, rescueOS, sshKeys)
+			rescue, err := api.Boot().EnableRescue(ctx, serverID, rescueOS, sshKeys)
 			if err != nil {
 				mu.Lock()
 				diags = append(diags, diag.Errorf("failed to enable rescue mode for server %d: %v", serverID, err)...)
@@ -167,28 +198,33 @@ func resourceBootInstallerCreate(ctx context.Context, d *schema.ResourceData, me
 				return
 			}
 
-			if err := cfg.RebootServer(ctx, serverID, "power"); err != nil {
+			if err := api.Reset().Reboot(ctx, serverID, "power"); err != nil {
 				mu.Lock()
 				diags = append(diags, diag.Errorf("failed to reboot server %d with power reset: %v", serverID, err)...)
 				mu.Unlock()
 				return
 			}
 
-			ip := rescueResp.Rescue.ServerIP
-			pass := rescueResp.Rescue.Password
+			ip := rescue.ServerIP
+			pass := rescue.Password
 
-			mu.Lock()
-			fmt.Printf("Connecting to server %s with password: %s\n", ip, pass)
-			mu.Unlock()
+			ctx := client.MaskSecret(api.LogContext(ctx, client.SubsystemInstaller), pass)
+			tflog.SubsystemInfo(ctx, client.SubsystemInstaller, "Connecting to rescue system", map[string]interface{}{
+				"server_number": serverID,
+				"server_ip":     ip,
+			})
 
-			if err := waitForSSH(ip, 3*time.Minute, 10*time.Second); err != nil {
+			// Whatever the resets left of the create timeout is available
+			// for the rescue system to come up.
+			sshTimeout := client.TimeLeft(ctx, createTimeout)
+			if err := api.Installer().WaitForSSH(ctx, ip, sshTimeout, sshPollInterval); err != nil {
 				mu.Lock()
 				diags = append(diags, diag.Errorf("SSH not available on server %d: %v", serverID, err)...)
 				mu.Unlock()
 				return
 			}
 
-			if err := cfg.InstallTalosOS(ctx, ip, pass); err != nil {
+			if err := api.Installer().InstallTalos(ctx, ip, pass); err != nil {
 				mu.Lock()
 				diags = append(diags, diag.Errorf("failed to install Talos OS on server %d: %v", serverID, err)...)
 				mu.Unlock()
@@ -197,21 +233,25 @@ func resourceBootInstallerCreate(ctx context.Context, d *schema.ResourceData, me
 
 			mu.Lock()
 			results = append(results, map[string]interface{}{
-				"id":       srv.ID,
-				"ip":       ip,
-				"password": pass,
+				"id":              srv.ID,
+				"ip":              ip,
+				"name":            srv.Name,
+				"rescue_password": pass,
 			})
 			mu.Unlock()
 		}(srv)
 	}
 	wg.Wait()
 	d.SetId("talos-installer")
-	d.Set("results", results)
+	if err := d.Set("results", results); err != nil {
+		diags = append(diags, diag.FromErr(err)...)
+	}
 	return diags
 }
 
 func resourceBootInstallerUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	cfg := meta.(*client.HetznerRobotClient)
+	api := meta.(client.RobotAPI)
+	ctx = client.WithAuditResource(ctx, "hetznerrobot_os_install", d.Id())
 	var diags diag.Diagnostics
 	if d.HasChange("servers") {
 		rawServers := d.Get("servers").([]interface{})
@@ -222,13 +262,13 @@ func resourceBootInstallerUpdate(ctx context.Context, d *schema.ResourceData, me
 				diags = append(diags, diag.Errorf("invalid server ID %s: %v", srv.ID, err)...)
 				continue
 			}
-			serverInfo, err := cfg.FetchServerByID(serverID)
+			serverInfo, err := api.Servers().Get(ctx, serverID)
 			if err != nil {
 				diags = append(diags, diag.Errorf("failed to fetch server %d info: %v", serverID, err)...)
 				continue
 			}
 			if serverInfo.ServerName != srv.Name {
-				_, err = cfg.RenameServer(ctx, serverID, srv.Name)
+				_, err = api.Servers().Rename(ctx, serverID, srv.Name)
 				if err != nil {
 					diags = append(diags, diag.Errorf("failed to rename server %d: %v", serverID, err)...)
 					continue
@@ -240,7 +280,8 @@ func resourceBootInstallerUpdate(ctx context.Context, d *schema.ResourceData, me
 }
 
 func resourceBootInstallerDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	cfg := meta.(*client.HetznerRobotClient)
+	api := meta.(client.RobotAPI)
+	ctx = client.WithAuditResource(ctx, "hetznerrobot_os_install", d.Id())
 	rawServers := d.Get("servers").([]interface{})
 	servers := expandServerList(rawServers)
 	var diags diag.Diagnostics
@@ -250,25 +291,10 @@ func resourceBootInstallerDelete(ctx context.Context, d *schema.ResourceData, me
 			diags = append(diags, diag.Errorf("invalid server ID %s: %v", srv.ID, err)...)
 			continue
 		}
-		_, err = cfg.ResetServer(ctx, serverID, "hw")
+		err = api.Reset().Reset(ctx, serverID, "hw")
 		if err != nil {
 			diags = append(diags, diag.Errorf("failed to reset server %d on delete: %v", serverID, err)...)
 		}
 	}
 	return diags
 }
-
-func waitForSSH(ip string, timeout time.Duration, interval time.Duration) error {
-	deadline := time.Now().Add(timeout)
-	for time.Now().Before(deadline) {
-		conn, err := net.DialTimeout("tcp", fmt.Sprintf("%s:22", ip), 5*time.Second)
-		if err == nil {
-			conn.Close()
-			fmt.Printf("[INFO] SSH доступен на сервере %s\n", ip)
-			return nil
-		}
-		fmt.Printf("[WARN] Ожидание SSH на %s... Повторная попытка через %v секунд\n", ip, interval.Seconds())
-		time.Sleep(interval)
-	}
-	return fmt.Errorf("SSH недоступен на %s после %v", ip, timeout)
-}
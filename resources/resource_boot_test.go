@@ -0,0 +1,156 @@
+package resources
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/client"
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/robotfake"
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/shared"
+)
+
+// shortenInstallerDelays makes the installation flow run at test speed.
+func shortenInstallerDelays(t *testing.T) {
+	settle, interval := installerSettleTime, sshPollInterval
+	installerSettleTime, sshPollInterval = time.Millisecond, 10*time.Millisecond
+	t.Cleanup(func() {
+		installerSettleTime, sshPollInterval = settle, interval
+	})
+}
+
+// installerContext stands in for the context the SDK bounds by the create
+// timeout.
+func installerContext(t *testing.T, timeout time.Duration) context.Context {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	t.Cleanup(cancel)
+	return ctx
+}
+
+func newInstallerTest(t *testing.T, configure ...func(*shared.ProviderConfig)) (*robotfake.Fake, *robotfake.RescueSSH, *client.HetznerRobotClient) {
+	t.Helper()
+	shortenInstallerDelays(t)
+	fake := robotfake.New(robotfake.Options{})
+	t.Cleanup(fake.Close)
+	fake.AddServer(robotfake.Server{Number: 321, Name: "old-name", IP: "127.0.0.1", Reset: true, Rescue: true})
+
+	rescue, err := fake.StartRescueSSH()
+	if err != nil {
+		t.Fatalf("StartRescueSSH: %v", err)
+	}
+	t.Cleanup(rescue.Close)
+
+	c := fake.NewClient(t, append([]func(*shared.ProviderConfig){func(c *shared.ProviderConfig) {
+		c.RescueSSHPort = rescue.Port()
+		c.PowerCycleWait = time.Millisecond
+	}}, configure...)...)
+	return fake, rescue, c
+}
+
+func installerData(t *testing.T) *schema.ResourceData {
+	return schema.TestResourceDataRaw(t, ResourceBootInstaller().Schema, map[string]interface{}{
+		"servers": []interface{}{
+			map[string]interface{}{"id": "321", "name": "talos-1"},
+		},
+	})
+}
+
+func TestResourceBootInstallerCreate(t *testing.T) {
+	fake, rescue, c := newInstallerTest(t)
+	d := installerData(t)
+
+	if diags := resourceBootInstallerCreate(installerContext(t, 3*time.Second), d, c); diags.HasError() {
+		t.Fatalf("create: %v", diags)
+	}
+
+	srv, _ := fake.Server(321)
+	if srv.Name != "talos-1" {
+		t.Errorf("server name = %q, want talos-1", srv.Name)
+	}
+	var resets []string
+	for _, r := range fake.Resets() {
+		resets = append(resets, r.Type)
+	}
+	if strings.Join(resets, ",") != "hw,power,power" {
+		t.Errorf("resets = %q, want hw followed by two presses of the power button", resets)
+	}
+	if rescue.Reboots() != 1 {
+		t.Errorf("rescue system rebooted %d times, want 1", rescue.Reboots())
+	}
+	if img := rescue.Disks()[0].Image; img == "" {
+		t.Error("no image written to the first disk")
+	}
+	if r, _ := fake.Rescue(321); r.Active {
+		t.Error("rescue system still active after the installation")
+	}
+	if got := d.Get("results.0.rescue_password").(string); got == "" {
+		t.Error("rescue_password not recorded in results")
+	}
+}
+
+func TestResourceBootInstallerCreateReportsInstallFailure(t *testing.T) {
+	fake, rescue, c := newInstallerTest(t)
+	rescue.FailNextCommand("zstd", 1, 1, "zstd: corrupted block detected\n")
+	d := installerData(t)
+
+	diags := resourceBootInstallerCreate(installerContext(t, 3*time.Second), d, c)
+	if !diags.HasError() {
+		t.Fatal("create succeeded, want error")
+	}
+	if rescue.Reboots() != 0 {
+		t.Errorf("rescue system rebooted %d times after a failed install", rescue.Reboots())
+	}
+	if r, _ := fake.Rescue(321); !r.Booted {
+		t.Error("server left the rescue system although the install failed")
+	}
+	if n := len(d.Get("results").([]interface{})); n != 0 {
+		t.Errorf("results has %d entries, want 0", n)
+	}
+}
+
+func TestResourceBootInstallerCreateStopsAtDeadline(t *testing.T) {
+	fake, rescue, c := newInstallerTest(t)
+	rescue.Close()
+	d := installerData(t)
+
+	const timeout = time.Second
+	start := time.Now()
+	diags := resourceBootInstallerCreate(installerContext(t, timeout), d, c)
+	elapsed := time.Since(start)
+	if !diags.HasError() {
+		t.Fatal("create succeeded without SSH, want error")
+	}
+	if !strings.Contains(diags[0].Summary, "SSH not available on server 321") {
+		t.Errorf("create failed with %q, want it to give up waiting for SSH", diags[0].Summary)
+	}
+	if elapsed < timeout*9/10 || elapsed > timeout+2*time.Second {
+		t.Errorf("create returned after %v, want it to wait for SSH until the %v deadline", elapsed, timeout)
+	}
+	resets := fake.Resets()
+	if len(resets) != 3 || resets[2].Type != "power" {
+		t.Errorf("resets = %+v, want the server turned on before waiting for SSH", resets)
+	}
+}
+
+func TestResourceBootInstallerCreateFailsWithoutTimeForPowerCycle(t *testing.T) {
+	fake, _, c := newInstallerTest(t, func(c *shared.ProviderConfig) {
+		c.PowerCycleWait = 30 * time.Second
+	})
+	d := installerData(t)
+
+	start := time.Now()
+	diags := resourceBootInstallerCreate(installerContext(t, time.Second), d, c)
+	if !diags.HasError() || !strings.Contains(diags[0].Summary, "less than the 30s power-cycle wait") {
+		t.Fatalf("create diags = %v, want an error that the power-cycle wait does not fit", diags)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("create returned after %v, want it to fail before waiting", elapsed)
+	}
+	resets := fake.Resets()
+	if len(resets) != 1 || resets[0].Type != "hw" {
+		t.Errorf("resets = %+v, want only the hw reset", resets)
+	}
+}
@@ -10,7 +10,7 @@ import (
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 
-	"hcloud-robot-provider/client"
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/client"
 )
 
 func ResourceFirewall() *schema.Resource {
@@ -22,6 +22,11 @@ func ResourceFirewall() *schema.Resource {
 		Importer: &schema.ResourceImporter{
 			StateContext: resourceFirewallImportState,
 		},
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(10 * time.Minute),
+			Update: schema.DefaultTimeout(10 * time.Minute),
+			Delete: schema.DefaultTimeout(10 * time.Minute),
+		},
 		Schema: map[string]*schema.Schema{
 			"server_id": {
 				Type:        schema.TypeString,
@@ -43,6 +48,13 @@ func ResourceFirewall() *schema.Resource {
 				Required: true,
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
+						"ip_version": {
+							Type:             schema.TypeString,
+							Optional:         true,
+							Default:          "ipv4",
+							ValidateDiagFunc: validation.ToDiagFunc(validation.StringInSlice([]string{"ipv4", "ipv6"}, false)),
+							Description:      "IP version the rule applies to (ipv4 or ipv6).",
+						},
 						"name":      {Type: schema.TypeString, Optional: true, Description: "Name of the firewall rule."},
 						"dst_ip":    {Type: schema.TypeString, Optional: true, Description: "Destination IP address."},
 						"dst_port":  {Type: schema.TypeString, Optional: true, Description: "Destination port."},
@@ -64,14 +76,21 @@ func ResourceFirewall() *schema.Resource {
 }
 
 func resourceFirewallCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	hClient := m.(*client.HetznerRobotClient)
+	return resourceFirewallApply(ctx, d, m, d.Timeout(schema.TimeoutCreate))
+}
+
+// resourceFirewallApply sets the configured firewall, retrying conflicts and
+// waiting for Robot to apply it within timeout.
+func resourceFirewallApply(ctx context.Context, d *schema.ResourceData, m interface{}, timeout time.Duration) diag.Diagnostics {
+	api := m.(client.RobotAPI)
 	serverID := d.Get("server_id").(string)
+	ctx = client.WithAuditResource(ctx, "hetznerrobot_firewall", serverID)
 	serverIDInt, err := strconv.Atoi(serverID)
 	if err != nil {
 		return diag.FromErr(fmt.Errorf("invalid server ID: %w", err))
 	}
 
-	server, err := hClient.FetchServerByID(serverIDInt)
+	server, err := api.Servers().Get(ctx, serverIDInt)
 	if err != nil {
 		return diag.FromErr(fmt.Errorf("error fetching server: %w", err))
 	}
@@ -83,12 +102,14 @@ func resourceFirewallCreate(ctx context.Context, d *schema.ResourceData, m inter
 
 	rules := buildFirewallRules(d.Get("rule").([]interface{}))
 
-	err = hClient.SetFirewall(ctx, client.HetznerRobotFirewall{
-		IP:                       server.IP,
-		WhitelistHetznerServices: d.Get("whitelist_hos").(bool),
-		Status:                   status,
-		Rules:                    client.HetznerRobotFirewallRules{Input: rules},
-	}, 20, 15*time.Second)
+	err = retryOnConflict(ctx, timeout, func() error {
+		return api.Firewalls().Set(ctx, client.HetznerRobotFirewall{
+			IP:                       server.IP,
+			WhitelistHetznerServices: d.Get("whitelist_hos").(bool),
+			Status:                   status,
+			Rules:                    client.HetznerRobotFirewallRules{Input: rules},
+		}, timeout)
+	})
 
 	if err != nil {
 		return diag.FromErr(err)
@@ -99,7 +120,7 @@ func resourceFirewallCreate(ctx context.Context, d *schema.ResourceData, m inter
 }
 
 func resourceFirewallRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	hClient := m.(*client.HetznerRobotClient)
+	api := m.(client.RobotAPI)
 	serverID := d.Get("server_id").(string)
 
 	serverIDInt, err := strconv.Atoi(serverID)
@@ -107,13 +128,21 @@ func resourceFirewallRead(ctx context.Context, d *schema.ResourceData, m interfa
 		return diag.FromErr(fmt.Errorf("invalid server ID: %w", err))
 	}
 
-	server, err := hClient.FetchServerByID(serverIDInt)
+	server, err := api.Servers().Get(ctx, serverIDInt)
 	if err != nil {
+		if client.IsNotFound(err) {
+			d.SetId("")
+			return nil
+		}
 		return diag.FromErr(fmt.Errorf("error fetching server: %w", err))
 	}
 
-	firewall, err := hClient.GetFirewall(ctx, server.IP)
+	firewall, err := api.Firewalls().Get(ctx, server.IP)
 	if err != nil {
+		if client.IsNotFound(err) {
+			d.SetId("")
+			return nil
+		}
 		return diag.FromErr(err)
 	}
 
@@ -125,43 +154,52 @@ func resourceFirewallRead(ctx context.Context, d *schema.ResourceData, m interfa
 }
 
 func resourceFirewallUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	return resourceFirewallCreate(ctx, d, m)
+	return resourceFirewallApply(ctx, d, m, d.Timeout(schema.TimeoutUpdate))
 }
 
 func resourceFirewallDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	hClient := m.(*client.HetznerRobotClient)
+	api := m.(client.RobotAPI)
 	serverID := d.Get("server_id").(string)
+	ctx = client.WithAuditResource(ctx, "hetznerrobot_firewall", serverID)
 
 	serverIDInt, err := strconv.Atoi(serverID)
 	if err != nil {
 		return diag.FromErr(fmt.Errorf("invalid server ID: %w", err))
 	}
 
-	server, err := hClient.FetchServerByID(serverIDInt)
+	server, err := api.Servers().Get(ctx, serverIDInt)
 	if err != nil {
+		if client.IsNotFound(err) {
+			d.SetId("")
+			return nil
+		}
 		return diag.FromErr(fmt.Errorf("error fetching server: %w", err))
 	}
 
 	// Установка правила, разрешающего весь трафик
-	err = hClient.SetFirewall(ctx, client.HetznerRobotFirewall{
-		IP:                       server.IP,
-		WhitelistHetznerServices: false,
-		Status:                   "active",
-		Rules: client.HetznerRobotFirewallRules{
-			Input: []client.HetznerRobotFirewallRule{
-				{
-					Name:     "Allow all",
-					Protocol: "",
-					DstIP:    "",
-					SrcIP:    "",
-					DstPort:  "",
-					SrcPort:  "",
-					TCPFlags: "",
-					Action:   "accept",
+	timeout := d.Timeout(schema.TimeoutDelete)
+	err = retryOnConflict(ctx, timeout, func() error {
+		return api.Firewalls().Set(ctx, client.HetznerRobotFirewall{
+			IP:                       server.IP,
+			WhitelistHetznerServices: false,
+			Status:                   "active",
+			Rules: client.HetznerRobotFirewallRules{
+				Input: []client.HetznerRobotFirewallRule{
+					{
+						IPVersion: "ipv4",
+						Name:      "Allow all",
+						Protocol:  "",
+						DstIP:     "",
+						SrcIP:     "",
+						DstPort:   "",
+						SrcPort:   "",
+						TCPFlags:  "",
+						Action:    "accept",
+					},
 				},
 			},
-		},
-	}, 20, 15*time.Second)
+		}, timeout)
+	})
 
 	if err != nil {
 		return diag.FromErr(fmt.Errorf("error setting firewall to allow all: %w", err))
@@ -172,7 +210,7 @@ func resourceFirewallDelete(ctx context.Context, d *schema.ResourceData, m inter
 }
 
 func resourceFirewallImportState(ctx context.Context, d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
-	hClient := m.(*client.HetznerRobotClient)
+	api := m.(client.RobotAPI)
 	serverID := d.Id()
 
 	serverIDInt, err := strconv.Atoi(serverID)
@@ -180,12 +218,12 @@ func resourceFirewallImportState(ctx context.Context, d *schema.ResourceData, m
 		return nil, fmt.Errorf("invalid server ID: %w", err)
 	}
 
-	server, err := hClient.FetchServerByID(serverIDInt)
+	server, err := api.Servers().Get(ctx, serverIDInt)
 	if err != nil {
 		return nil, fmt.Errorf("error fetching server: %w", err)
 	}
 
-	firewall, err := hClient.GetFirewall(ctx, server.IP)
+	firewall, err := api.Firewalls().Get(ctx, server.IP)
 	if err != nil {
 		return nil, fmt.Errorf("could not find firewall for server ID %s: %w", serverID, err)
 	}
@@ -205,14 +243,15 @@ func buildFirewallRules(ruleList []interface{}) []client.HetznerRobotFirewallRul
 	for _, ruleMap := range ruleList {
 		ruleProps := ruleMap.(map[string]interface{})
 		rules = append(rules, client.HetznerRobotFirewallRule{
-			Name:     ruleProps["name"].(string),
-			DstIP:    ruleProps["dst_ip"].(string),
-			DstPort:  ruleProps["dst_port"].(string),
-			SrcIP:    ruleProps["src_ip"].(string),
-			SrcPort:  ruleProps["src_port"].(string),
-			Protocol: ruleProps["protocol"].(string),
-			TCPFlags: ruleProps["tcp_flags"].(string),
-			Action:   ruleProps["action"].(string),
+			IPVersion: ruleProps["ip_version"].(string),
+			Name:      ruleProps["name"].(string),
+			DstIP:     ruleProps["dst_ip"].(string),
+			DstPort:   ruleProps["dst_port"].(string),
+			SrcIP:     ruleProps["src_ip"].(string),
+			SrcPort:   ruleProps["src_port"].(string),
+			Protocol:  ruleProps["protocol"].(string),
+			TCPFlags:  ruleProps["tcp_flags"].(string),
+			Action:    ruleProps["action"].(string),
 		})
 	}
 	return rules
@@ -221,15 +260,22 @@ func buildFirewallRules(ruleList []interface{}) []client.HetznerRobotFirewallRul
 func flattenFirewallRules(rules []client.HetznerRobotFirewallRule) []map[string]interface{} {
 	var result []map[string]interface{}
 	for _, rule := range rules {
+		// Robot returns null for rules without an IP version, which the
+		// schema defaults to ipv4.
+		ipVersion := rule.IPVersion
+		if ipVersion == "" {
+			ipVersion = "ipv4"
+		}
 		result = append(result, map[string]interface{}{
-			"name":      rule.Name,
-			"dst_ip":    rule.DstIP,
-			"dst_port":  rule.DstPort,
-			"src_ip":    rule.SrcIP,
-			"src_port":  rule.SrcPort,
-			"protocol":  rule.Protocol,
-			"tcp_flags": rule.TCPFlags,
-			"action":    rule.Action,
+			"ip_version": ipVersion,
+			"name":       rule.Name,
+			"dst_ip":     rule.DstIP,
+			"dst_port":   rule.DstPort,
+			"src_ip":     rule.SrcIP,
+			"src_port":   rule.SrcPort,
+			"protocol":   rule.Protocol,
+			"tcp_flags":  rule.TCPFlags,
+			"action":     rule.Action,
 		})
 	}
 	return result
@@ -0,0 +1,151 @@
+package resources_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/client"
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/resources"
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/robotfake"
+)
+
+func TestAccFirewall_basic(t *testing.T) {
+	fake := robotfake.New(robotfake.Options{})
+	defer fake.Close()
+	fake.AddServer(robotfake.Server{Number: 321, Name: "node-1", IP: "203.0.113.10"})
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy:      testAccCheckFirewallAllowsAll(fake, 321),
+		Steps: []resource.TestStep{
+			{
+				Config: fake.ProviderConfig() + testAccFirewallConfig("22"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("hetznerrobot_firewall.test", "active", "true"),
+					resource.TestCheckResourceAttr("hetznerrobot_firewall.test", "rule.#", "2"),
+					resource.TestCheckResourceAttr("hetznerrobot_firewall.test", "rule.0.dst_port", "22"),
+					testAccCheckFirewallRules(fake, 321, 2),
+				),
+			},
+			{
+				Config: fake.ProviderConfig() + testAccFirewallConfig("2222"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("hetznerrobot_firewall.test", "rule.0.dst_port", "2222"),
+				),
+			},
+			{
+				ResourceName:      "hetznerrobot_firewall.test",
+				ImportState:       true,
+				ImportStateId:     "321",
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func TestAccFirewall_serverGone(t *testing.T) {
+	fake := robotfake.New(robotfake.Options{})
+	defer fake.Close()
+	fake.AddServer(robotfake.Server{Number: 321, Name: "node-1", IP: "203.0.113.10"})
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fake.ProviderConfig() + testAccFirewallConfig("22"),
+			},
+			{
+				PreConfig: func() {
+					fake.FailNext("GET", "/firewall/203.0.113.10", 1, 404, "SERVER_NOT_FOUND", "Server not found")
+				},
+				Config:             fake.ProviderConfig() + testAccFirewallConfig("22"),
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+		},
+	})
+}
+
+func TestFirewallReadDefaultsIPVersion(t *testing.T) {
+	fake := robotfake.New(robotfake.Options{})
+	defer fake.Close()
+	fake.AddServer(robotfake.Server{Number: 321, IP: "203.0.113.10"})
+	c := fake.NewClient(t)
+	err := c.Firewalls().Set(context.Background(), client.HetznerRobotFirewall{
+		IP:     "203.0.113.10",
+		Status: "active",
+		Rules: client.HetznerRobotFirewallRules{Input: []client.HetznerRobotFirewallRule{
+			{Name: "no version", Action: "accept"},
+			{IPVersion: "ipv6", Name: "v6", Action: "accept"},
+		}},
+	}, 0)
+	if err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	r := resources.ResourceFirewall()
+	d := r.TestResourceData()
+	d.SetId("321")
+	d.Set("server_id", "321")
+	if diags := r.ReadContext(context.Background(), d, c); diags.HasError() {
+		t.Fatalf("read: %v", diags)
+	}
+	if got := d.Get("rule.0.ip_version"); got != "ipv4" {
+		t.Errorf("rule.0.ip_version = %q, want the ipv4 default", got)
+	}
+	if got := d.Get("rule.1.ip_version"); got != "ipv6" {
+		t.Errorf("rule.1.ip_version = %q, want ipv6", got)
+	}
+}
+
+func testAccFirewallConfig(port string) string {
+	return fmt.Sprintf(`
+resource "hetznerrobot_firewall" "test" {
+  server_id     = "321"
+  active        = true
+  whitelist_hos = true
+
+  rule {
+    name     = "ssh"
+    dst_port = %q
+    protocol = "tcp"
+    action   = "accept"
+  }
+
+  rule {
+    name   = "drop"
+    action = "discard"
+  }
+}
+`, port)
+}
+
+func testAccCheckFirewallRules(fake *robotfake.Fake, server, want int) resource.TestCheckFunc {
+	return func(*terraform.State) error {
+		fw, ok := fake.Firewall(server)
+		if !ok {
+			return fmt.Errorf("no firewall for server %d", server)
+		}
+		if len(fw.Rules) != want {
+			return fmt.Errorf("firewall has %d rules, want %d", len(fw.Rules), want)
+		}
+		return nil
+	}
+}
+
+func testAccCheckFirewallAllowsAll(fake *robotfake.Fake, server int) resource.TestCheckFunc {
+	return func(*terraform.State) error {
+		fw, ok := fake.Firewall(server)
+		if !ok {
+			return fmt.Errorf("no firewall for server %d", server)
+		}
+		if len(fw.Rules) != 1 || fw.Rules[0].Action != "accept" {
+			return fmt.Errorf("firewall was not reset to allow all: %+v", fw.Rules)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,468 @@
+package resources
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/client"
+)
+
+// Cancellation policies of hetznerrobot_server, applied on destroy.
+const (
+	// cancellationPolicyPrevent makes destroy fail, so that a server is
+	// never lost to a refactoring of the configuration.
+	cancellationPolicyPrevent = "prevent"
+	// cancellationPolicyDetach removes the server from the state and
+	// leaves it running.
+	cancellationPolicyDetach = "detach"
+	// cancellationPolicyCancel cancels the server, by default at the end of
+	// its billing period.
+	cancellationPolicyCancel = "cancel"
+	// cancellationPolicyReverse withdraws the order of the server while
+	// that is still possible and cancels it otherwise.
+	cancellationPolicyReverse = "reverse"
+)
+
+// ResourceServer adopts an existing dedicated server. Robot servers are
+// bought, not created, so create only looks the server up and applies the
+// managed attributes.
+func ResourceServer() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceServerCreate,
+		ReadContext:   resourceServerRead,
+		UpdateContext: resourceServerUpdate,
+		DeleteContext: resourceServerDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: resourceServerImportState,
+		},
+		CustomizeDiff: resourceServerCustomizeDiff,
+		Schema: map[string]*schema.Schema{
+			"server_number": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Computed:     true,
+				ForceNew:     true,
+				ExactlyOneOf: []string{"server_number", "server_ip"},
+				Description:  "Number of the server to adopt.",
+			},
+			"server_ip": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.IsIPAddress,
+				Description:  "Main IP of the server to adopt, as an alternative to server_number.",
+			},
+			"server_name": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "Name of the server in Robot. Left unchanged if not set.",
+			},
+			"cancellation_policy": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  cancellationPolicyPrevent,
+				ValidateFunc: validation.StringInSlice([]string{
+					cancellationPolicyPrevent,
+					cancellationPolicyDetach,
+					cancellationPolicyCancel,
+					cancellationPolicyReverse,
+				}, false),
+				Description: "What destroy does: \"prevent\" fails, \"detach\" only removes the server from the state and leaves it running, \"cancel\" cancels the server in Robot, \"reverse\" withdraws the order of a freshly ordered server and cancels it if the withdrawal period is over.",
+			},
+			"cancellation_date": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateCancellationDate,
+				Description:  "Date (YYYY-MM-DD) or \"now\" at which destroy cancels the server with cancellation_policy \"cancel\". Defaults to the end of the billing period (paid_until), or the earliest cancellation date if that is later.",
+			},
+			"cancellation_reason": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Reason given when cancelling the server. Must be one of the reasons Robot offers for the server.",
+			},
+			"reversal_reason": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Reason given when withdrawing the order with cancellation_policy \"reverse\".",
+			},
+			"reserve_location": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Reserve the location of the server when cancelling it, so that it can be reused by a new order.",
+			},
+			"ipv6_net":   {Type: schema.TypeString, Computed: true},
+			"product":    {Type: schema.TypeString, Computed: true},
+			"datacenter": {Type: schema.TypeString, Computed: true},
+			"traffic":    {Type: schema.TypeString, Computed: true},
+			"status":     {Type: schema.TypeString, Computed: true},
+			"cancelled":  {Type: schema.TypeBool, Computed: true},
+			"paid_until": {Type: schema.TypeString, Computed: true},
+			"ips": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Single IP addresses of the server.",
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"subnets": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Subnets routed to the server.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"ip":   {Type: schema.TypeString, Computed: true},
+						"mask": {Type: schema.TypeString, Computed: true},
+					},
+				},
+			},
+			"capabilities": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Which Robot features are available for the server.",
+				Elem:        serverCapabilitiesSchema(),
+			},
+			"linked_storagebox": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "ID of the storage box linked to the server, or 0.",
+			},
+		},
+	}
+}
+
+func serverCapabilitiesSchema() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"reset":    {Type: schema.TypeBool, Computed: true, Description: "Hardware and software resets are available."},
+			"rescue":   {Type: schema.TypeBool, Computed: true, Description: "The rescue system is available."},
+			"vnc":      {Type: schema.TypeBool, Computed: true, Description: "VNC installation is available."},
+			"windows":  {Type: schema.TypeBool, Computed: true, Description: "Windows installation is available."},
+			"plesk":    {Type: schema.TypeBool, Computed: true, Description: "Plesk installation is available."},
+			"cpanel":   {Type: schema.TypeBool, Computed: true, Description: "cPanel installation is available."},
+			"wol":      {Type: schema.TypeBool, Computed: true, Description: "Wake on LAN is available."},
+			"hot_swap": {Type: schema.TypeBool, Computed: true, Description: "The server has hot-swappable disks."},
+		},
+	}
+}
+
+func resourceServerCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(client.RobotAPI)
+
+	var (
+		server *client.Server
+		err    error
+	)
+	if number, ok := d.GetOk("server_number"); ok {
+		server, err = api.Servers().Get(ctx, number.(int))
+	} else {
+		server, err = api.Servers().GetByIP(ctx, d.Get("server_ip").(string))
+	}
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("error looking up server: %w", err))
+	}
+	d.SetId(strconv.Itoa(server.Number))
+	ctx = client.WithAuditResource(ctx, "hetznerrobot_server", d.Id())
+
+	if name, ok := d.GetOk("server_name"); ok && name.(string) != server.ServerName {
+		if _, err := api.Servers().Rename(ctx, server.Number, name.(string)); err != nil {
+			return diag.FromErr(fmt.Errorf("error renaming server %d: %w", server.Number, err))
+		}
+	}
+
+	return resourceServerRead(ctx, d, meta)
+}
+
+func resourceServerRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(client.RobotAPI)
+	number, err := strconv.Atoi(d.Id())
+	if err != nil {
+		return diag.Errorf("invalid server number %q: %v", d.Id(), err)
+	}
+
+	server, err := api.Servers().Fetch(ctx, number)
+	if err != nil {
+		if client.IsNotFound(err) {
+			tflog.Warn(ctx, "Server not found, removing from state", map[string]interface{}{"server_number": number})
+			d.SetId("")
+			return nil
+		}
+		return diag.FromErr(fmt.Errorf("error reading server: %w", err))
+	}
+
+	if err := setServerAttributes(d, server); err != nil {
+		return diag.FromErr(err)
+	}
+	return nil
+}
+
+func resourceServerUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(client.RobotAPI)
+	ctx = client.WithAuditResource(ctx, "hetznerrobot_server", d.Id())
+	number, err := strconv.Atoi(d.Id())
+	if err != nil {
+		return diag.Errorf("invalid server number %q: %v", d.Id(), err)
+	}
+
+	if d.HasChange("server_name") {
+		if _, err := api.Servers().Rename(ctx, number, d.Get("server_name").(string)); err != nil {
+			return diag.FromErr(fmt.Errorf("error renaming server %d: %w", number, err))
+		}
+	}
+
+	return resourceServerRead(ctx, d, meta)
+}
+
+func resourceServerDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	switch policy := d.Get("cancellation_policy").(string); policy {
+	case cancellationPolicyCancel:
+		return resourceServerCancel(ctx, d, meta)
+	case cancellationPolicyReverse:
+		return resourceServerReverse(ctx, d, meta)
+	case cancellationPolicyDetach:
+		tflog.Info(ctx, "Detaching server, it keeps running", map[string]interface{}{"server_number": d.Id()})
+		d.SetId("")
+		return nil
+	default:
+		return diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  "Server is protected from destroy",
+			Detail: fmt.Sprintf("Server %s has cancellation_policy %q. Set cancellation_policy = %q and apply "+
+				"before destroying to remove it from Terraform without touching the server.",
+				d.Id(), policy, cancellationPolicyDetach),
+		}}
+	}
+}
+
+// resourceServerCancel schedules the cancellation of the server and removes
+// it from the state. A server that is already cancelled is left as it is.
+func resourceServerCancel(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(client.RobotAPI)
+	ctx = client.WithAuditResource(ctx, "hetznerrobot_server", d.Id())
+	number, err := strconv.Atoi(d.Id())
+	if err != nil {
+		return diag.Errorf("invalid server number %q: %v", d.Id(), err)
+	}
+
+	current, err := api.Servers().Cancellation(ctx, number)
+	if err != nil {
+		if client.IsNotFound(err) {
+			d.SetId("")
+			return nil
+		}
+		return diag.FromErr(err)
+	}
+	if current.Cancelled {
+		tflog.Info(ctx, "Server is already cancelled", map[string]interface{}{
+			"server_number":     number,
+			"cancellation_date": current.CancellationDate,
+		})
+		d.SetId("")
+		return nil
+	}
+
+	date := d.Get("cancellation_date").(string)
+	if date == "" {
+		date = defaultCancellationDate(d.Get("paid_until").(string), current.EarliestCancellationDate)
+	}
+	cancellation, err := api.Servers().Cancel(ctx, number, client.CancelRequest{
+		Date:            date,
+		Reason:          d.Get("cancellation_reason").(string),
+		ReserveLocation: d.Get("reserve_location").(bool),
+	})
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	tflog.Info(ctx, "Cancelled server", map[string]interface{}{
+		"server_number":     number,
+		"cancellation_date": cancellation.CancellationDate,
+	})
+	d.SetId("")
+	return nil
+}
+
+// resourceServerReverse withdraws the order of the server if Robot still
+// allows it, and cancels the server otherwise.
+func resourceServerReverse(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(client.RobotAPI)
+	ctx = client.WithAuditResource(ctx, "hetznerrobot_server", d.Id())
+	number, err := strconv.Atoi(d.Id())
+	if err != nil {
+		return diag.Errorf("invalid server number %q: %v", d.Id(), err)
+	}
+
+	reversal, err := api.Servers().Reversal(ctx, number)
+	if err != nil {
+		if client.IsNotFound(err) {
+			d.SetId("")
+			return nil
+		}
+		return diag.FromErr(err)
+	}
+	if reversal.Reversed {
+		tflog.Info(ctx, "Server order is already reversed", map[string]interface{}{"server_number": number})
+		d.SetId("")
+		return nil
+	}
+	if !reversal.ReversalPossible {
+		tflog.Info(ctx, "Server order can no longer be reversed, cancelling instead", map[string]interface{}{"server_number": number})
+		return resourceServerCancel(ctx, d, meta)
+	}
+
+	if _, err := api.Servers().Reverse(ctx, number, d.Get("reversal_reason").(string)); err != nil {
+		return diag.FromErr(err)
+	}
+	tflog.Info(ctx, "Reversed server order", map[string]interface{}{"server_number": number})
+	d.SetId("")
+	return nil
+}
+
+// defaultCancellationDate is the end of the billing period, unless Robot
+// does not allow cancelling before a later date. Both are YYYY-MM-DD, so
+// they compare as strings.
+func defaultCancellationDate(paidUntil, earliest string) string {
+	if paidUntil == "" || paidUntil < earliest {
+		return earliest
+	}
+	return paidUntil
+}
+
+func validateCancellationDate(v interface{}, k string) ([]string, []error) {
+	value := v.(string)
+	if value == "now" {
+		return nil, nil
+	}
+	if _, err := time.Parse(time.DateOnly, value); err != nil {
+		return nil, []error{fmt.Errorf("%s must be \"now\" or a date in the format YYYY-MM-DD, got %q", k, value)}
+	}
+	return nil, nil
+}
+
+// resourceServerCustomizeDiff checks the cancellation settings against the
+// options Robot offers for the server, so that a date or reason it would
+// reject fails the plan instead of the destroy.
+func resourceServerCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	// "reverse" falls back to a cancellation, so it is checked as well.
+	if policy := d.Get("cancellation_policy").(string); policy != cancellationPolicyCancel && policy != cancellationPolicyReverse {
+		return nil
+	}
+	if d.Id() != "" && !d.HasChanges("cancellation_policy", "cancellation_date", "cancellation_reason") {
+		return nil
+	}
+	api := meta.(client.RobotAPI)
+
+	var number int
+	switch {
+	case d.Id() != "":
+		n, err := strconv.Atoi(d.Id())
+		if err != nil {
+			return fmt.Errorf("invalid server number %q: %w", d.Id(), err)
+		}
+		number = n
+	case d.NewValueKnown("server_number") && d.Get("server_number").(int) != 0:
+		number = d.Get("server_number").(int)
+	case d.NewValueKnown("server_ip") && d.Get("server_ip").(string) != "":
+		server, err := api.Servers().GetByIP(ctx, d.Get("server_ip").(string))
+		if err != nil {
+			return fmt.Errorf("error looking up server: %w", err)
+		}
+		number = server.Number
+	default:
+		return nil
+	}
+
+	cancellation, err := api.Servers().Cancellation(ctx, number)
+	if err != nil {
+		return fmt.Errorf("error fetching cancellation options of server %d: %w", number, err)
+	}
+	if cancellation.Cancelled {
+		return nil
+	}
+	if date := d.Get("cancellation_date").(string); date != "" && date != "now" && date < cancellation.EarliestCancellationDate {
+		return fmt.Errorf("cancellation_date %s is before the earliest cancellation date %s of server %d",
+			date, cancellation.EarliestCancellationDate, number)
+	}
+	if reason := d.Get("cancellation_reason").(string); reason != "" && !slices.Contains(cancellation.Reasons, reason) {
+		return fmt.Errorf("cancellation_reason %q is not accepted for server %d, choose one of: %s",
+			reason, number, strings.Join(cancellation.Reasons, "; "))
+	}
+	return nil
+}
+
+// resourceServerImportState accepts a server number or main IP.
+func resourceServerImportState(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	api := meta.(client.RobotAPI)
+	id := d.Id()
+
+	if strings.ContainsAny(id, ".:") {
+		server, err := api.Servers().GetByIP(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("error looking up server with IP %s: %w", id, err)
+		}
+		d.SetId(strconv.Itoa(server.Number))
+	} else if _, err := strconv.Atoi(id); err != nil {
+		return nil, fmt.Errorf("invalid import ID %q: want a server number or IP", id)
+	}
+	d.Set("cancellation_policy", cancellationPolicyPrevent)
+	d.Set("reserve_location", false)
+
+	return []*schema.ResourceData{d}, nil
+}
+
+func setServerAttributes(d *schema.ResourceData, server *client.Server) error {
+	linkedStoragebox := 0
+	if server.LinkedStoragebox != nil {
+		linkedStoragebox = *server.LinkedStoragebox
+	}
+	values := map[string]interface{}{
+		"server_number":     server.Number,
+		"server_ip":         server.IP,
+		"server_name":       server.ServerName,
+		"ipv6_net":          server.IPv6Net,
+		"product":           server.Product,
+		"datacenter":        server.Datacenter,
+		"traffic":           server.Traffic,
+		"status":            server.Status,
+		"cancelled":         server.Cancelled,
+		"paid_until":        server.PaidUntil,
+		"ips":               server.IPs,
+		"subnets":           flattenServerSubnets(server.Subnets),
+		"capabilities":      flattenServerCapabilities(server),
+		"linked_storagebox": linkedStoragebox,
+	}
+	for key, value := range values {
+		if err := d.Set(key, value); err != nil {
+			return fmt.Errorf("error setting %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+func flattenServerSubnets(subnets []client.ServerSubnet) []map[string]interface{} {
+	result := make([]map[string]interface{}, 0, len(subnets))
+	for _, s := range subnets {
+		result = append(result, map[string]interface{}{
+			"ip":   s.IP,
+			"mask": s.Mask,
+		})
+	}
+	return result
+}
+
+func flattenServerCapabilities(server *client.Server) []map[string]interface{} {
+	capabilities := make(map[string]interface{})
+	for name, available := range server.Capabilities() {
+		capabilities[name] = available
+	}
+	return []map[string]interface{}{capabilities}
+}
@@ -0,0 +1,177 @@
+package resources
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/client"
+)
+
+var addonTransactions = orderTransactions[*client.AddonTransaction]{
+	what:          "Addon",
+	assignedAttrs: "ip or subnet",
+	get: func(o client.OrderingService) func(context.Context, string) (*client.AddonTransaction, error) {
+		return o.AddonTransaction
+	},
+	wait: func(o client.OrderingService) func(context.Context, string, time.Duration) (*client.AddonTransaction, error) {
+		return o.WaitAddonTransaction
+	},
+	status:     func(tx *client.AddonTransaction) string { return tx.Status },
+	isAssigned: func(tx *client.AddonTransaction) bool { return len(tx.Resources) > 0 },
+	set:        setAddonTransaction,
+}
+
+// ResourceServerAddonOrder orders an addon such as an additional IPv4
+// address or subnet for a server and exposes what was allocated. Like the
+// server orders, destroying it does not cancel the addon.
+func ResourceServerAddonOrder() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceServerAddonOrderCreate,
+		ReadContext:   addonTransactions.read,
+		DeleteContext: resourceServerAddonOrderDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: resourceServerAddonOrderImportState,
+		},
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(30 * time.Minute),
+		},
+		Schema: map[string]*schema.Schema{
+			"test": {
+				Type:        schema.TypeBool,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Only validate the order without placing it. Must be set explicitly; set it to false to buy the addon.",
+			},
+			"server_number": {
+				Type:         schema.TypeInt,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.IntAtLeast(1),
+				Description:  "Number of the server to order the addon for.",
+			},
+			"product_id": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "ID of the addon, see hetznerrobot_server_addon, e.g. \"additional_ipv4\".",
+			},
+			"reason": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "Why the addresses are needed. Required by RIPE for additional IPv4 addresses.",
+			},
+			"gateway": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "Gateway for the subnet, if the addon is a subnet.",
+			},
+			"status": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Status of the transaction: \"in process\", \"ready\" or \"cancelled\".",
+			},
+			"date": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"resources": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "What was allocated, once the order is ready.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"type": {Type: schema.TypeString, Computed: true, Description: "\"ip\" or \"subnet\"."},
+						"id":   {Type: schema.TypeString, Computed: true, Description: "The address, or the network address of the subnet."},
+					},
+				},
+			},
+			"ip": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The allocated IP, if the addon is an IP.",
+			},
+			"subnet": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Network address of the allocated subnet, if the addon is a subnet.",
+			},
+		},
+	}
+}
+
+func resourceServerAddonOrderCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	api := m.(client.RobotAPI)
+	number := d.Get("server_number").(int)
+	ctx = client.WithAuditResource(ctx, "hetznerrobot_server_addon_order", "")
+
+	order := client.AddonOrder{
+		ServerNumber: number,
+		ProductID:    d.Get("product_id").(string),
+		Reason:       d.Get("reason").(string),
+		Gateway:      d.Get("gateway").(string),
+		Test:         d.Get("test").(bool),
+	}
+	tx, err := api.Ordering().OrderAddon(ctx, order)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(tx.ID)
+	ctx = client.WithAuditResource(ctx, "hetznerrobot_server_addon_order", tx.ID)
+	return addonTransactions.placed(ctx, d, m, tx)
+}
+
+func resourceServerAddonOrderDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	if d.Get("test").(bool) {
+		d.SetId("")
+		return nil
+	}
+	allocated := d.Get("ip").(string)
+	if allocated == "" {
+		allocated = d.Get("subnet").(string)
+	}
+	d.SetId("")
+	return diag.Diagnostics{{
+		Severity: diag.Warning,
+		Summary:  "Ordered addon was not cancelled",
+		Detail:   fmt.Sprintf("Removing hetznerrobot_server_addon_order only forgets the order. %s stays assigned to server %d and keeps being billed until it is cancelled in Robot.", allocated, d.Get("server_number").(int)),
+	}}
+}
+
+func resourceServerAddonOrderImportState(ctx context.Context, d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
+	api := m.(client.RobotAPI)
+	tx, err := api.Ordering().AddonTransaction(ctx, d.Id())
+	if err != nil {
+		return nil, fmt.Errorf("could not find transaction %s: %w", d.Id(), err)
+	}
+	d.Set("test", false)
+	d.Set("server_number", tx.ServerNumber)
+	d.Set("product_id", tx.Product.ID)
+	setAddonTransaction(d, tx)
+	return []*schema.ResourceData{d}, nil
+}
+
+func setAddonTransaction(d *schema.ResourceData, tx *client.AddonTransaction) {
+	d.Set("status", tx.Status)
+	d.Set("date", tx.Date)
+	resources := make([]map[string]interface{}, 0, len(tx.Resources))
+	var ip, subnet string
+	for _, r := range tx.Resources {
+		resources = append(resources, map[string]interface{}{"type": r.Type, "id": r.ID})
+		switch {
+		case r.Type == "ip" && ip == "":
+			ip = r.ID
+		case r.Type == "subnet" && subnet == "":
+			subnet = r.ID
+		}
+	}
+	d.Set("resources", resources)
+	d.Set("ip", ip)
+	d.Set("subnet", subnet)
+}
@@ -0,0 +1,90 @@
+package resources_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/resources"
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/robotfake"
+)
+
+func TestAccServerAddonOrder_subnet(t *testing.T) {
+	fake := robotfake.New(robotfake.Options{})
+	defer fake.Close()
+	fake.AddServer(robotfake.Server{Number: 321, IP: "203.0.113.10"})
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fake.ProviderConfig() + `
+resource "hetznerrobot_server_addon_order" "test" {
+  test          = false
+  server_number = 321
+  product_id    = "subnet_ipv4_29"
+}
+`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("hetznerrobot_server_addon_order.test", "status", "ready"),
+					resource.TestCheckResourceAttr("hetznerrobot_server_addon_order.test", "resources.0.type", "subnet"),
+					resource.TestCheckResourceAttrSet("hetznerrobot_server_addon_order.test", "subnet"),
+					resource.TestCheckResourceAttr("hetznerrobot_server_addon_order.test", "ip", ""),
+				),
+			},
+		},
+	})
+}
+
+func TestServerAddonOrderCreate(t *testing.T) {
+	fake := robotfake.New(robotfake.Options{PendingPolls: 1})
+	defer fake.Close()
+	fake.AddServer(robotfake.Server{Number: 321, IP: "203.0.113.10"})
+	c := fake.NewClient(t)
+
+	r := resources.ResourceServerAddonOrder()
+	for _, test := range []bool{true, false} {
+		d := r.TestResourceData()
+		d.Set("test", test)
+		d.Set("server_number", 321)
+		d.Set("product_id", "additional_ipv4")
+		d.Set("reason", "Failover for the ingress")
+		if diags := r.CreateContext(context.Background(), d, c); diags.HasError() {
+			t.Fatalf("create with test = %v: %v", test, diags)
+		}
+		ip := d.Get("ip").(string)
+		if test != (ip == "") {
+			t.Errorf("create with test = %v: ip = %q", test, ip)
+		}
+	}
+	if srv, _ := fake.Server(321); len(srv.IPs) != 2 {
+		t.Errorf("server IPs = %v, want one additional IP", srv.IPs)
+	}
+}
+
+func TestServerAddonOrderReadKeepsPurgedOrder(t *testing.T) {
+	fake := robotfake.New(robotfake.Options{})
+	defer fake.Close()
+	fake.AddServer(robotfake.Server{Number: 321, IP: "203.0.113.10"})
+	c := fake.NewClient(t)
+
+	r := resources.ResourceServerAddonOrder()
+	d := r.TestResourceData()
+	d.Set("test", false)
+	d.Set("server_number", 321)
+	d.Set("product_id", "subnet_ipv4_29")
+	if diags := r.CreateContext(context.Background(), d, c); diags.HasError() {
+		t.Fatal(diags)
+	}
+	subnet := d.Get("subnet").(string)
+
+	// Robot forgets the transaction after about 30 days; the subnet stays.
+	fake.PurgeAddonTransaction(d.Id())
+	if diags := r.ReadContext(context.Background(), d, c); diags.HasError() {
+		t.Fatalf("read purged: %v", diags)
+	}
+	if d.Id() == "" || subnet == "" || d.Get("subnet") != subnet {
+		t.Errorf("read purged: id = %q, subnet = %v, want the stored %q kept", d.Id(), d.Get("subnet"), subnet)
+	}
+}
@@ -0,0 +1,60 @@
+package resources
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/client"
+)
+
+var serverMarketOrder = serverOrderKind{
+	resourceType: "hetznerrobot_server_market_order",
+	place: func(ctx context.Context, ordering client.OrderingService, d *schema.ResourceData) (*client.ServerTransaction, error) {
+		return ordering.OrderMarketServer(ctx, client.MarketOrder{
+			ProductID:      d.Get("product_id").(int),
+			AuthorizedKeys: expandStringList(d.Get("authorized_keys").([]interface{})),
+			Password:       d.Get("password").(string),
+			Dist:           d.Get("dist").(string),
+			Lang:           d.Get("lang").(string),
+			Comment:        d.Get("comment").(string),
+			Addons:         expandStringList(d.Get("addons").([]interface{})),
+			Test:           d.Get("test").(bool),
+		})
+	},
+	get: func(o client.OrderingService) func(context.Context, string) (*client.ServerTransaction, error) {
+		return o.MarketTransaction
+	},
+	wait: func(o client.OrderingService) func(context.Context, string, time.Duration) (*client.ServerTransaction, error) {
+		return o.WaitMarketTransaction
+	},
+	importProduct: func(d *schema.ResourceData, tx *client.ServerTransaction) error {
+		id, err := strconv.Atoi(tx.Product.ID)
+		if err != nil {
+			return fmt.Errorf("transaction %s is not a server market order: product %q", tx.ID, tx.Product.ID)
+		}
+		return d.Set("product_id", id)
+	},
+}
+
+// ResourceServerMarketOrder buys a server from the server market (auction),
+// typically one picked with the hetznerrobot_server_market data source. Like
+// hetznerrobot_server_order, destroying it does not cancel the server.
+func ResourceServerMarketOrder() *schema.Resource {
+	s := serverOrderSchema()
+	s["product_id"] = &schema.Schema{
+		Type:        schema.TypeInt,
+		Required:    true,
+		ForceNew:    true,
+		Description: "Number of the server market product to buy.",
+	}
+	s["datacenter"] = &schema.Schema{
+		Type:        schema.TypeString,
+		Computed:    true,
+		Description: "Datacenter of the ordered server.",
+	}
+	return serverMarketOrder.resource(s)
+}
@@ -0,0 +1,244 @@
+package resources
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/client"
+)
+
+// serverOrderKind is a catalog servers can be ordered from. Orders from the
+// standard catalog and the server market only differ in their endpoints and
+// in how the product is chosen; their transactions behave the same.
+type serverOrderKind struct {
+	resourceType string
+	place        func(ctx context.Context, ordering client.OrderingService, d *schema.ResourceData) (*client.ServerTransaction, error)
+	get          func(ordering client.OrderingService) func(ctx context.Context, id string) (*client.ServerTransaction, error)
+	wait         func(ordering client.OrderingService) func(ctx context.Context, id string, timeout time.Duration) (*client.ServerTransaction, error)
+	// importProduct sets the product arguments from an imported
+	// transaction.
+	importProduct func(d *schema.ResourceData, tx *client.ServerTransaction) error
+}
+
+var serverOrder = serverOrderKind{
+	resourceType: "hetznerrobot_server_order",
+	place: func(ctx context.Context, ordering client.OrderingService, d *schema.ResourceData) (*client.ServerTransaction, error) {
+		return ordering.OrderServer(ctx, client.ServerOrder{
+			ProductID:      d.Get("product_id").(string),
+			AuthorizedKeys: expandStringList(d.Get("authorized_keys").([]interface{})),
+			Password:       d.Get("password").(string),
+			Location:       d.Get("location").(string),
+			Dist:           d.Get("dist").(string),
+			Lang:           d.Get("lang").(string),
+			Comment:        d.Get("comment").(string),
+			Addons:         expandStringList(d.Get("addons").([]interface{})),
+			Test:           d.Get("test").(bool),
+		})
+	},
+	get: func(o client.OrderingService) func(context.Context, string) (*client.ServerTransaction, error) {
+		return o.ServerTransaction
+	},
+	wait: func(o client.OrderingService) func(context.Context, string, time.Duration) (*client.ServerTransaction, error) {
+		return o.WaitServerTransaction
+	},
+	importProduct: func(d *schema.ResourceData, tx *client.ServerTransaction) error {
+		return d.Set("product_id", tx.Product.ID)
+	},
+}
+
+// ResourceServerOrder orders a server from the standard product catalog.
+// Destroying it does not cancel the server: adopt the ordered server with
+// hetznerrobot_server to manage its name and cancellation.
+func ResourceServerOrder() *schema.Resource {
+	s := serverOrderSchema()
+	s["product_id"] = &schema.Schema{
+		Type:        schema.TypeString,
+		Required:    true,
+		ForceNew:    true,
+		Description: "ID of the product to order, e.g. \"AX41-NVMe\".",
+	}
+	s["location"] = &schema.Schema{
+		Type:        schema.TypeString,
+		Optional:    true,
+		Computed:    true,
+		ForceNew:    true,
+		Description: "Location to order the server in, e.g. \"FSN1\". May be omitted if the product is only offered in one location.",
+	}
+	s["addons"].Description = "IDs of addons to order with the server, see orderable_addons of hetznerrobot_server_product."
+	return serverOrder.resource(s)
+}
+
+// serverOrderSchema returns the arguments and attributes shared by all
+// server orders.
+func serverOrderSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"test": {
+			Type:        schema.TypeBool,
+			Required:    true,
+			ForceNew:    true,
+			Description: "Only validate the order without placing it. Must be set explicitly; set it to false to buy the server.",
+		},
+		"dist": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			ForceNew:    true,
+			Description: "Distribution to install on the server.",
+		},
+		"lang": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			ForceNew:    true,
+			Description: "Language of the installed distribution.",
+		},
+		"authorized_keys": {
+			Type:        schema.TypeList,
+			Optional:    true,
+			ForceNew:    true,
+			Description: "Fingerprints of SSH keys stored in Robot to authorize on the server.",
+			Elem:        &schema.Schema{Type: schema.TypeString},
+		},
+		"password": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			ForceNew:    true,
+			Sensitive:   true,
+			Description: "Root password of the server. Only used if no authorized_keys are given.",
+		},
+		"comment": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			ForceNew:    true,
+			Description: "Comment for the order. Orders with a comment are processed manually by Hetzner.",
+		},
+		"addons": {
+			Type:        schema.TypeList,
+			Optional:    true,
+			ForceNew:    true,
+			Description: "IDs of addons to order with the server.",
+			Elem:        &schema.Schema{Type: schema.TypeString},
+		},
+		"status": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "Status of the transaction: \"in process\", \"ready\" or \"cancelled\".",
+		},
+		"date": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"server_number": {
+			Type:        schema.TypeInt,
+			Computed:    true,
+			Description: "Number of the ordered server, or 0 until it has been provisioned and for test orders.",
+		},
+		"server_ip": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "Main IP of the ordered server, once provisioned.",
+		},
+	}
+}
+
+// transactions follows the server orders of k.
+func (k serverOrderKind) transactions() orderTransactions[*client.ServerTransaction] {
+	return orderTransactions[*client.ServerTransaction]{
+		what:          "Server",
+		assignedAttrs: "server_number and server_ip",
+		get:           k.get,
+		wait:          k.wait,
+		status:        func(tx *client.ServerTransaction) string { return tx.Status },
+		isAssigned:    func(tx *client.ServerTransaction) bool { return tx.ServerNumber != 0 },
+		set:           setServerTransaction,
+	}
+}
+
+func (k serverOrderKind) resource(s map[string]*schema.Schema) *schema.Resource {
+	return &schema.Resource{
+		CreateContext: k.create,
+		ReadContext:   k.transactions().read,
+		DeleteContext: k.delete,
+		Importer: &schema.ResourceImporter{
+			StateContext: k.importState,
+		},
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(2 * time.Hour),
+		},
+		Schema: s,
+	}
+}
+
+func (k serverOrderKind) create(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	api := m.(client.RobotAPI)
+	ctx = client.WithAuditResource(ctx, k.resourceType, "")
+	ctx = client.MaskSecret(ctx, d.Get("password").(string))
+
+	tx, err := k.place(ctx, api.Ordering(), d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(tx.ID)
+	ctx = client.WithAuditResource(ctx, k.resourceType, tx.ID)
+	return k.transactions().placed(ctx, d, m, tx)
+}
+
+func (k serverOrderKind) delete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	if d.Get("test").(bool) {
+		d.SetId("")
+		return nil
+	}
+	number := d.Get("server_number").(int)
+	d.SetId("")
+	return diag.Diagnostics{{
+		Severity: diag.Warning,
+		Summary:  "Ordered server was not cancelled",
+		Detail:   fmt.Sprintf("Removing %s only forgets the order. Server %d keeps running and being billed; cancel it with hetznerrobot_server and cancellation_policy = \"cancel\" or \"reverse\".", k.resourceType, number),
+	}}
+}
+
+func (k serverOrderKind) importState(ctx context.Context, d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
+	api := m.(client.RobotAPI)
+	tx, err := k.get(api.Ordering())(ctx, d.Id())
+	if err != nil {
+		return nil, fmt.Errorf("could not find transaction %s: %w", d.Id(), err)
+	}
+	if err := k.importProduct(d, tx); err != nil {
+		return nil, err
+	}
+	d.Set("test", false)
+	if tx.Product.Dist != "" {
+		d.Set("dist", tx.Product.Dist)
+	}
+	if tx.Product.Lang != "" {
+		d.Set("lang", tx.Product.Lang)
+	}
+	d.Set("comment", tx.Comment)
+	d.Set("addons", tx.Addons)
+	setServerTransaction(d, tx)
+	return []*schema.ResourceData{d}, nil
+}
+
+func setServerTransaction(d *schema.ResourceData, tx *client.ServerTransaction) {
+	d.Set("status", tx.Status)
+	d.Set("date", tx.Date)
+	d.Set("server_number", tx.ServerNumber)
+	d.Set("server_ip", tx.ServerIP)
+	// Standard orders have a location, market orders a datacenter.
+	if tx.Product.Location != "" {
+		d.Set("location", tx.Product.Location)
+	}
+	if tx.Product.Datacenter != "" {
+		d.Set("datacenter", tx.Product.Datacenter)
+	}
+}
+
+func expandStringList(list []interface{}) []string {
+	out := make([]string, 0, len(list))
+	for _, v := range list {
+		out = append(out, v.(string))
+	}
+	return out
+}
@@ -0,0 +1,179 @@
+package resources_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/client"
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/resources"
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/robotfake"
+)
+
+func TestAccServerOrder_test(t *testing.T) {
+	fake := robotfake.New(robotfake.Options{})
+	defer fake.Close()
+	fake.AddServerProduct(robotfake.ServerProduct{ID: "AX41-NVMe", Name: "AX41-NVMe", Locations: []string{"FSN1"}, MonthlyNet: "39.0000"})
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fake.ProviderConfig() + `
+resource "hetznerrobot_server_order" "test" {
+  test       = true
+  product_id = "AX41-NVMe"
+  password   = "correct horse battery staple"
+}
+`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("hetznerrobot_server_order.test", "location", "FSN1"),
+					resource.TestCheckResourceAttr("hetznerrobot_server_order.test", "server_number", "0"),
+				),
+			},
+		},
+	})
+}
+
+func TestServerOrderCreate(t *testing.T) {
+	fake := robotfake.New(robotfake.Options{PendingPolls: 1})
+	defer fake.Close()
+	fake.AddServerProduct(robotfake.ServerProduct{ID: "AX41-NVMe", Name: "AX41-NVMe", Locations: []string{"FSN1"}, MonthlyNet: "39.0000"})
+	c := fake.NewClient(t)
+
+	r := resources.ResourceServerOrder()
+	for _, test := range []bool{true, false} {
+		d := r.TestResourceData()
+		d.Set("test", test)
+		d.Set("product_id", "AX41-NVMe")
+		if diags := r.CreateContext(context.Background(), d, c); diags.HasError() {
+			t.Fatalf("create with test = %v: %v", test, diags)
+		}
+		if d.Id() == "" {
+			t.Fatalf("create with test = %v did not set an ID", test)
+		}
+		number := d.Get("server_number").(int)
+		if _, exists := fake.Server(number); exists == test {
+			t.Errorf("create with test = %v: server %d exists = %v", test, number, exists)
+		}
+	}
+	if n := len(fake.ServerTransactions()); n != 2 {
+		t.Errorf("%d orders placed, want 2", n)
+	}
+}
+
+func TestServerMarketOrderCreate(t *testing.T) {
+	fake := robotfake.New(robotfake.Options{})
+	defer fake.Close()
+	fake.AddMarketProduct(robotfake.MarketProduct{ID: 283693, Name: "SB36", Datacenter: "HEL1-DC2", Price: "31.0000"})
+	c := fake.NewClient(t)
+
+	r := resources.ResourceServerMarketOrder()
+	d := r.TestResourceData()
+	d.Set("test", false)
+	d.Set("product_id", 283693)
+	if diags := r.CreateContext(context.Background(), d, c); diags.HasError() {
+		t.Fatal(diags)
+	}
+	if d.Get("datacenter") != "HEL1-DC2" || d.Get("server_number").(int) == 0 {
+		t.Errorf("datacenter = %v, server_number = %v", d.Get("datacenter"), d.Get("server_number"))
+	}
+
+	// Robot forgets the transaction after about 30 days; the server stays.
+	number := d.Get("server_number").(int)
+	fake.PurgeServerTransaction(d.Id())
+	if diags := r.ReadContext(context.Background(), d, c); diags.HasError() {
+		t.Fatalf("read purged: %v", diags)
+	}
+	if d.Id() == "" || d.Get("server_number").(int) != number || d.Get("datacenter") != "HEL1-DC2" {
+		t.Errorf("read purged: id = %q, server_number = %v, datacenter = %v, want the stored state kept", d.Id(), d.Get("server_number"), d.Get("datacenter"))
+	}
+
+	diags := r.DeleteContext(context.Background(), d, c)
+	if diags.HasError() || len(diags) != 1 || !strings.Contains(diags[0].Detail, "keeps running") {
+		t.Errorf("delete diags = %v, want a warning that the server is not cancelled", diags)
+	}
+	if _, ok := fake.Server(d.Get("server_number").(int)); !ok {
+		t.Error("delete removed the ordered server")
+	}
+}
+
+func TestServerOrderRead(t *testing.T) {
+	fake := robotfake.New(robotfake.Options{PendingPolls: 2})
+	defer fake.Close()
+	fake.AddServerProduct(robotfake.ServerProduct{ID: "AX41-NVMe", Name: "AX41-NVMe", Locations: []string{"FSN1"}, MonthlyNet: "39.0000"})
+	c := fake.NewClient(t)
+	r := resources.ResourceServerOrder()
+	// pending places an order and stores it as a create that timed out
+	// would.
+	pending := func() *schema.ResourceData {
+		tx, err := c.Ordering().OrderServer(context.Background(), client.ServerOrder{ProductID: "AX41-NVMe"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		d := r.TestResourceData()
+		d.SetId(tx.ID)
+		d.Set("test", false)
+		d.Set("product_id", "AX41-NVMe")
+		return d
+	}
+
+	// A refresh records a pending order without waiting for it.
+	d := pending()
+	if diags := r.ReadContext(context.Background(), d, c); diags.HasError() {
+		t.Fatalf("read pending: %v", diags)
+	}
+	if d.Get("status") != "in process" || d.Get("server_number").(int) != 0 {
+		t.Fatalf("read pending: status = %v, server_number = %v, want the order in process", d.Get("status"), d.Get("server_number"))
+	}
+	for i := 0; i < 2 && d.Get("status") != "ready"; i++ {
+		if diags := r.ReadContext(context.Background(), d, c); diags.HasError() {
+			t.Fatalf("read pending: %v", diags)
+		}
+	}
+	number := d.Get("server_number").(int)
+	if _, ok := fake.Server(number); !ok || d.Get("status") != "ready" {
+		t.Fatalf("read ready: server_number = %d, status = %v, want the provisioned server", number, d.Get("status"))
+	}
+
+	// Robot forgets the transaction after about 30 days; the server stays.
+	fake.PurgeServerTransaction(d.Id())
+	if diags := r.ReadContext(context.Background(), d, c); diags.HasError() {
+		t.Fatalf("read purged: %v", diags)
+	}
+	if d.Id() == "" || d.Get("server_number").(int) != number {
+		t.Errorf("read purged: id = %q, server_number = %v, want the stored state kept", d.Id(), d.Get("server_number"))
+	}
+
+	// A transaction purged before a server was assigned is reported.
+	d = pending()
+	if diags := r.ReadContext(context.Background(), d, c); diags.HasError() {
+		t.Fatalf("read pending: %v", diags)
+	}
+	fake.PurgeServerTransaction(d.Id())
+	diags := r.ReadContext(context.Background(), d, c)
+	if diags.HasError() || len(diags) != 1 || !strings.Contains(diags[0].Detail, "will not be filled in") {
+		t.Errorf("read purged pending: diags = %v, want a warning that server_number stays unset", diags)
+	}
+	if d.Id() == "" {
+		t.Error("read purged pending: removed the order from state")
+	}
+
+	// An order cancelled before a server was assigned is gone.
+	d = pending()
+	fake.CancelServerTransaction(d.Id())
+	for i := 0; i < 3 && d.Id() != ""; i++ {
+		if diags := r.ReadContext(context.Background(), d, c); diags.HasError() {
+			t.Fatalf("read cancelled: %v", diags)
+		}
+	}
+	if d.Id() != "" {
+		t.Errorf("read cancelled: id = %q, want the order removed from state", d.Id())
+	}
+	if n := len(fake.ServerTransactions()); n != 3 {
+		t.Errorf("%d orders placed, want 3", n)
+	}
+}
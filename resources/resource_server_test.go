@@ -0,0 +1,166 @@
+package resources_test
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/resources"
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/robotfake"
+)
+
+func TestAccServer_adoptByIP(t *testing.T) {
+	fake := robotfake.New(robotfake.Options{})
+	defer fake.Close()
+	fake.AddServer(robotfake.Server{Number: 321, Name: "old-name", IP: "203.0.113.10", IPv6Net: "2001:db8:1::", Reset: true, WOL: true})
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fake.ProviderConfig() + testAccServerConfig(`server_ip = "203.0.113.10"`, "node-1", "detach"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("hetznerrobot_server.test", "id", "321"),
+					resource.TestCheckResourceAttr("hetznerrobot_server.test", "server_number", "321"),
+					resource.TestCheckResourceAttr("hetznerrobot_server.test", "server_name", "node-1"),
+					resource.TestCheckResourceAttr("hetznerrobot_server.test", "subnets.0.ip", "2001:db8:1::"),
+					resource.TestCheckResourceAttr("hetznerrobot_server.test", "capabilities.0.reset", "true"),
+					resource.TestCheckResourceAttr("hetznerrobot_server.test", "capabilities.0.vnc", "false"),
+					testAccCheckServerName(fake, 321, "node-1"),
+				),
+			},
+			{
+				ResourceName:            "hetznerrobot_server.test",
+				ImportState:             true,
+				ImportStateId:           "203.0.113.10",
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"cancellation_policy"},
+			},
+		},
+		// With the detach policy destroy leaves the server alone.
+		CheckDestroy: testAccCheckServerName(fake, 321, "node-1"),
+	})
+}
+
+func TestAccServer_preventDestroy(t *testing.T) {
+	fake := robotfake.New(robotfake.Options{})
+	defer fake.Close()
+	fake.AddServer(robotfake.Server{Number: 321, Name: "node-1", IP: "203.0.113.10"})
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fake.ProviderConfig() + testAccServerConfig("server_number = 321", "node-1", "prevent"),
+			},
+			{
+				Config:      fake.ProviderConfig() + testAccServerConfig("server_number = 321", "node-1", "prevent"),
+				Destroy:     true,
+				ExpectError: regexp.MustCompile("protected from destroy"),
+			},
+			{
+				Config: fake.ProviderConfig() + testAccServerConfig("server_number = 321", "node-1", "detach"),
+			},
+		},
+	})
+}
+
+func TestServerDeleteHonorsCancellationPolicy(t *testing.T) {
+	r := resources.ResourceServer()
+	for policy, wantErr := range map[string]bool{"prevent": true, "detach": false} {
+		d := r.TestResourceData()
+		d.SetId("321")
+		if err := d.Set("cancellation_policy", policy); err != nil {
+			t.Fatal(err)
+		}
+		diags := r.DeleteContext(context.Background(), d, nil)
+		if diags.HasError() != wantErr {
+			t.Errorf("policy %s: diags = %v, want error %v", policy, diags, wantErr)
+		}
+		if (d.Id() == "") == wantErr {
+			t.Errorf("policy %s: ID = %q after delete", policy, d.Id())
+		}
+	}
+}
+
+func TestAccServer_cancelOnDestroy(t *testing.T) {
+	fake := robotfake.New(robotfake.Options{})
+	defer fake.Close()
+	fake.AddServer(robotfake.Server{Number: 321, Name: "node-1", IP: "203.0.113.10", PaidUntil: "2030-01-31"})
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config:      fake.ProviderConfig() + testAccServerConfig("server_number = 321", "node-1", "cancel", `cancellation_date = "2030-01-01"`),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile("before the earliest cancellation date"),
+			},
+			{
+				Config: fake.ProviderConfig() + testAccServerConfig("server_number = 321", "node-1", "cancel"),
+			},
+		},
+		CheckDestroy: func(*terraform.State) error {
+			srv, _ := fake.Server(321)
+			if !srv.Cancelled || srv.CancellationDate != "2030-01-31" {
+				return fmt.Errorf("server cancelled = %v on %q, want cancelled at the end of the billing period", srv.Cancelled, srv.CancellationDate)
+			}
+			return nil
+		},
+	})
+}
+
+func testAccServerConfig(selector, name, policy string, extra ...string) string {
+	return fmt.Sprintf(`
+resource "hetznerrobot_server" "test" {
+  %s
+  server_name         = %q
+  cancellation_policy = %q
+  %s
+}
+`, selector, name, policy, strings.Join(extra, "\n  "))
+}
+
+func testAccCheckServerName(fake *robotfake.Fake, number int, want string) resource.TestCheckFunc {
+	return func(*terraform.State) error {
+		srv, ok := fake.Server(number)
+		if !ok {
+			return fmt.Errorf("server %d is gone", number)
+		}
+		if srv.Name != want {
+			return fmt.Errorf("server %d is named %q, want %q", number, srv.Name, want)
+		}
+		return nil
+	}
+}
+
+func TestServerDeleteReversesOrCancels(t *testing.T) {
+	fake := robotfake.New(robotfake.Options{})
+	defer fake.Close()
+	fake.AddServer(robotfake.Server{Number: 321, IP: "203.0.113.10", ReversalPossible: true})
+	fake.AddServer(robotfake.Server{Number: 322, IP: "203.0.113.11", PaidUntil: "2030-01-31"})
+	c := fake.NewClient(t)
+
+	r := resources.ResourceServer()
+	for _, id := range []string{"321", "322"} {
+		d := r.TestResourceData()
+		d.SetId(id)
+		d.Set("cancellation_policy", "reverse")
+		d.Set("paid_until", "2030-01-31")
+		if diags := r.DeleteContext(context.Background(), d, c); diags.HasError() {
+			t.Fatalf("delete %s: %v", id, diags)
+		}
+	}
+
+	if srv, _ := fake.Server(321); !srv.Reversed || srv.Cancelled {
+		t.Errorf("server 321 reversed = %v, cancelled = %v, want reversed", srv.Reversed, srv.Cancelled)
+	}
+	if srv, _ := fake.Server(322); srv.Reversed || !srv.Cancelled || srv.CancellationDate != "2030-01-31" {
+		t.Errorf("server 322 reversed = %v, cancelled = %v on %q, want cancelled at paid_until", srv.Reversed, srv.Cancelled, srv.CancellationDate)
+	}
+}
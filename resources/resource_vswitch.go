@@ -6,13 +6,13 @@ import (
 	"math/rand"
 	"sort"
 	"strconv"
-	"strings"
 	"time"
 
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 
-	"hcloud-robot-provider/client"
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/client"
 )
 
 func ResourceVSwitch() *schema.Resource {
@@ -21,6 +21,11 @@ func ResourceVSwitch() *schema.Resource {
 		ReadContext:   resourceVSwitchRead,
 		UpdateContext: resourceVSwitchUpdate,
 		DeleteContext: resourceVSwitchDelete,
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(10 * time.Minute),
+			Update: schema.DefaultTimeout(10 * time.Minute),
+			Delete: schema.DefaultTimeout(5 * time.Minute),
+		},
 
 		Schema: map[string]*schema.Schema{
 			"name": {
@@ -56,7 +61,8 @@ func ResourceVSwitch() *schema.Resource {
 }
 
 func resourceVSwitchCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	c := meta.(*client.HetznerRobotClient)
+	c := meta.(client.RobotAPI)
+	ctx = client.WithAuditResource(ctx, "hetznerrobot_vswitch", "")
 
 	name := d.Get("name").(string)
 
@@ -75,7 +81,7 @@ func resourceVSwitchCreate(ctx context.Context, d *schema.ResourceData, meta int
 		_ = d.Set("vlan", chosenVLAN)
 	}
 
-	vsw, err := c.CreateVSwitch(ctx, name, chosenVLAN)
+	vsw, err := c.VSwitches().Create(ctx, name, chosenVLAN)
 	if err != nil {
 		return diag.FromErr(fmt.Errorf("error creating vSwitch: %w", err))
 	}
@@ -84,7 +90,10 @@ func resourceVSwitchCreate(ctx context.Context, d *schema.ResourceData, meta int
 		serverIDs := parseServerIDs(servers.([]interface{}))
 		serverObjects := parseServerIDsToVSwitchServers(serverIDs)
 		if len(serverObjects) > 0 {
-			if err := c.AddVSwitchServers(ctx, strconv.Itoa(vsw.ID), serverObjects); err != nil {
+			err := retryOnConflict(ctx, d.Timeout(schema.TimeoutCreate), func() error {
+				return c.VSwitches().AddServers(ctx, vsw.ID, serverObjects)
+			})
+			if err != nil {
 				return diag.FromErr(fmt.Errorf("error adding servers to vSwitch: %w", err))
 			}
 		}
@@ -96,13 +105,18 @@ func resourceVSwitchCreate(ctx context.Context, d *schema.ResourceData, meta int
 }
 
 func resourceVSwitchRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	c := meta.(*client.HetznerRobotClient)
+	c := meta.(client.RobotAPI)
 	id := d.Id()
+	ctx = c.LogContext(ctx, client.SubsystemVSwitch)
+	vswitchID, err := strconv.Atoi(id)
+	if err != nil {
+		return diag.Errorf("invalid vSwitch ID %q: %v", id, err)
+	}
 
-	vsw, err := c.FetchVSwitchByIDWithContext(ctx, id)
+	vsw, err := c.VSwitches().Get(ctx, vswitchID)
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			fmt.Printf("vSwitch with ID %s not found, marking for recreation\n", id)
+		if client.IsNotFound(err) {
+			tflog.SubsystemWarn(ctx, client.SubsystemVSwitch, "vSwitch not found, removing from state", map[string]interface{}{"vswitch_id": id})
 			d.SetId("")
 			return nil
 		}
@@ -121,29 +135,41 @@ func resourceVSwitchRead(ctx context.Context, d *schema.ResourceData, meta inter
 	for _, server := range vsw.Servers {
 		if server.Status == "failed" {
 			message := fmt.Sprintf("Server %d failed to connect. Please check in the Hetzner web interface.", server.ServerNumber)
-			fmt.Println("[WARNING]", message)
+			tflog.SubsystemWarn(ctx, client.SubsystemVSwitch, message, map[string]interface{}{
+				"vswitch_id":    id,
+				"server_number": server.ServerNumber,
+			})
 			incidents = append(incidents, message)
 		}
 	}
 	d.Set("incidents", incidents)
 
-	fmt.Printf("[INFO] Successfully read vSwitch ID: %s\n", id)
+	tflog.SubsystemDebug(ctx, client.SubsystemVSwitch, "Read vSwitch", map[string]interface{}{"vswitch_id": id})
 	return nil
 }
 
 func resourceVSwitchUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	c := meta.(*client.HetznerRobotClient)
+	c := meta.(client.RobotAPI)
 	id := d.Id()
+	ctx = client.WithAuditResource(ctx, "hetznerrobot_vswitch", id)
+	vswitchID, err := strconv.Atoi(id)
+	if err != nil {
+		return diag.Errorf("invalid vSwitch ID %q: %v", id, err)
+	}
 
 	name := d.Get("name").(string)
 	vlan := d.Get("vlan").(int)
+	timeout := d.Timeout(schema.TimeoutUpdate)
 
 	var waitForReady bool
 
 	if d.HasChange("name") || d.HasChange("vlan") {
 		oldVlan, _ := d.GetChange("vlan")
 
-		if err := c.UpdateVSwitch(ctx, id, name, vlan, oldVlan.(int)); err != nil {
+		err := retryOnConflict(ctx, timeout, func() error {
+			return c.VSwitches().Update(ctx, vswitchID, name, vlan, oldVlan.(int))
+		})
+		if err != nil {
 			return diag.FromErr(fmt.Errorf("error updating vSwitch: %w", err))
 		}
 
@@ -161,14 +187,20 @@ func resourceVSwitchUpdate(ctx context.Context, d *schema.ResourceData, meta int
 
 		if len(toRemove) > 0 {
 			removeObjects := parseServerIDsToVSwitchServers(toRemove)
-			if err := c.RemoveVSwitchServers(ctx, id, removeObjects); err != nil {
+			err := retryOnConflict(ctx, timeout, func() error {
+				return c.VSwitches().RemoveServers(ctx, vswitchID, removeObjects)
+			})
+			if err != nil {
 				return diag.FromErr(fmt.Errorf("error removing servers from vSwitch: %w", err))
 			}
 		}
 
 		if len(toAdd) > 0 {
 			addObjects := parseServerIDsToVSwitchServers(toAdd)
-			if err := c.AddVSwitchServers(ctx, id, addObjects); err != nil {
+			err := retryOnConflict(ctx, timeout, func() error {
+				return c.VSwitches().AddServers(ctx, vswitchID, addObjects)
+			})
+			if err != nil {
 				return diag.FromErr(fmt.Errorf("error adding servers to vSwitch: %w", err))
 			}
 		}
@@ -176,25 +208,43 @@ func resourceVSwitchUpdate(ctx context.Context, d *schema.ResourceData, meta int
 		waitForReady = true
 	}
 
+	var diags diag.Diagnostics
 	if waitForReady {
-		if err := c.WaitForVSwitchReady(ctx, id, 20, 15*time.Second); err != nil {
+		err := c.VSwitches().WaitReady(ctx, vswitchID, timeout)
+		switch {
+		case client.IsOperationFailed(err):
+			// Failed assignments are reported as incidents by Read.
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Warning,
+				Summary:  "vSwitch server assignment failed",
+				Detail:   err.Error(),
+			})
+		case err != nil:
 			return diag.FromErr(fmt.Errorf("error waiting for vSwitch readiness after update: %w", err))
 		}
 	}
 
-	return resourceVSwitchRead(ctx, d, meta)
+	return append(diags, resourceVSwitchRead(ctx, d, meta)...)
 }
 
 func resourceVSwitchDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	c := meta.(*client.HetznerRobotClient)
+	c := meta.(client.RobotAPI)
 	id := d.Id()
+	ctx = client.WithAuditResource(ctx, "hetznerrobot_vswitch", id)
+	vswitchID, err := strconv.Atoi(id)
+	if err != nil {
+		return diag.Errorf("invalid vSwitch ID %q: %v", id, err)
+	}
 
 	cancellationDate := d.Get("cancellation_date").(string)
 	if cancellationDate == "" {
 		cancellationDate = "now"
 	}
 
-	if err := c.DeleteVSwitch(ctx, id, cancellationDate); err != nil {
+	err = retryOnConflict(ctx, d.Timeout(schema.TimeoutDelete), func() error {
+		return c.VSwitches().Delete(ctx, vswitchID, cancellationDate)
+	})
+	if err != nil && !client.IsNotFound(err) {
 		return diag.FromErr(fmt.Errorf("error deleting vSwitch: %w", err))
 	}
 
@@ -228,8 +278,8 @@ func flattenServers(servers []client.VSwitchServer) []int {
 	return result
 }
 
-func pickRandomFreeVLAN(ctx context.Context, c *client.HetznerRobotClient) (int, error) {
-	vswitches, err := c.FetchAllVSwitches(ctx)
+func pickRandomFreeVLAN(ctx context.Context, c client.RobotAPI) (int, error) {
+	vswitches, err := c.VSwitches().List(ctx)
 	if err != nil {
 		return 0, fmt.Errorf("fetch all vswitches error: %w", err)
 	}
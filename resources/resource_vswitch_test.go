@@ -0,0 +1,134 @@
+package resources_test
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/client"
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/resources"
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/robotfake"
+)
+
+func TestAccVSwitch_basic(t *testing.T) {
+	fake := robotfake.New(robotfake.Options{})
+	defer fake.Close()
+	fake.AddServer(robotfake.Server{Number: 321, Name: "node-1", IP: "203.0.113.10"})
+	fake.AddServer(robotfake.Server{Number: 322, Name: "node-2", IP: "203.0.113.11"})
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy:      testAccCheckVSwitchDestroyed(fake),
+		Steps: []resource.TestStep{
+			{
+				Config: fake.ProviderConfig() + testAccVSwitchConfig("private", "321"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("hetznerrobot_vswitch.test", "name", "private"),
+					resource.TestCheckResourceAttr("hetznerrobot_vswitch.test", "vlan", "4010"),
+					resource.TestCheckResourceAttr("hetznerrobot_vswitch.test", "servers.#", "1"),
+				),
+			},
+			{
+				Config: fake.ProviderConfig() + testAccVSwitchConfig("private-renamed", "321, 322"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("hetznerrobot_vswitch.test", "name", "private-renamed"),
+					resource.TestCheckResourceAttr("hetznerrobot_vswitch.test", "servers.#", "2"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccVSwitch_deletedOutsideTerraform(t *testing.T) {
+	fake := robotfake.New(robotfake.Options{})
+	defer fake.Close()
+	fake.AddServer(robotfake.Server{Number: 321, Name: "node-1", IP: "203.0.113.10"})
+
+	var id string
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fake.ProviderConfig() + testAccVSwitchConfig("private", "321"),
+				Check: func(s *terraform.State) error {
+					id = s.RootModule().Resources["hetznerrobot_vswitch.test"].Primary.ID
+					return nil
+				},
+			},
+			{
+				PreConfig: func() {
+					fake.FailNext("GET", "/vswitch/"+id, 1, 404, "NOT_FOUND", "vSwitch not found")
+				},
+				Config:             fake.ProviderConfig() + testAccVSwitchConfig("private", "321"),
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+		},
+	})
+}
+
+func testAccVSwitchConfig(name, servers string) string {
+	return fmt.Sprintf(`
+resource "hetznerrobot_vswitch" "test" {
+  name    = %q
+  vlan    = 4010
+  servers = [%s]
+}
+`, name, servers)
+}
+
+func testAccCheckVSwitchDestroyed(fake *robotfake.Fake) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		for _, rs := range s.RootModule().Resources {
+			if rs.Type != "hetznerrobot_vswitch" {
+				continue
+			}
+			id, err := strconv.Atoi(rs.Primary.ID)
+			if err != nil {
+				return err
+			}
+			if _, ok := fake.VSwitch(id); ok {
+				return fmt.Errorf("vSwitch %d still exists", id)
+			}
+		}
+		return nil
+	}
+}
+
+// stubAPI is a RobotAPI whose vSwitch service is replaced; every other
+// service panics when used.
+type stubAPI struct {
+	client.RobotAPI
+	vswitches client.VSwitchService
+}
+
+func (s stubAPI) VSwitches() client.VSwitchService { return s.vswitches }
+
+func (s stubAPI) LogContext(ctx context.Context, _ string) context.Context { return ctx }
+
+type stubVSwitches struct {
+	client.VSwitchService
+	get func(id int) (*client.VSwitch, error)
+}
+
+func (s stubVSwitches) Get(_ context.Context, id int) (*client.VSwitch, error) { return s.get(id) }
+
+func TestVSwitchReadRemovesMissingVSwitch(t *testing.T) {
+	api := stubAPI{vswitches: stubVSwitches{get: func(id int) (*client.VSwitch, error) {
+		return nil, &client.RobotError{StatusCode: 404, Code: "NOT_FOUND", Message: fmt.Sprintf("vSwitch %d not found", id)}
+	}}}
+	r := resources.ResourceVSwitch()
+	d := r.TestResourceData()
+	d.SetId("4711")
+
+	if diags := r.ReadContext(context.Background(), d, api); diags.HasError() {
+		t.Fatalf("Read: %v", diags)
+	}
+	if d.Id() != "" {
+		t.Errorf("ID = %q, want the vSwitch removed from state", d.Id())
+	}
+}
@@ -0,0 +1,24 @@
+package resources
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/retry"
+
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/client"
+)
+
+// retryOnConflict runs f until it succeeds, fails with an error other than a
+// Robot conflict (e.g. VSWITCH_IN_PROCESS), or the timeout expires.
+func retryOnConflict(ctx context.Context, timeout time.Duration, f func() error) error {
+	return retry.RetryContext(ctx, timeout, func() *retry.RetryError {
+		if err := f(); err != nil {
+			if client.IsConflict(err) {
+				return retry.RetryableError(err)
+			}
+			return retry.NonRetryableError(err)
+		}
+		return nil
+	})
+}
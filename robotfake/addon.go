@@ -0,0 +1,236 @@
+package robotfake
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AddonProduct is an addon the fake offers for every server.
+type AddonProduct struct {
+	ID   string
+	Name string
+	// Type is "ip_ipv4" or "subnet_ipv4". IPv4 addresses require a reason.
+	Type string
+	// Mask is the prefix length of subnets.
+	Mask       int
+	MonthlyNet string
+}
+
+// AddonProducts are the addons the fake offers.
+var AddonProducts = []AddonProduct{
+	{ID: "additional_ipv4", Name: "Additional IP address", Type: "ip_ipv4", MonthlyNet: "1.7000"},
+	{ID: "subnet_ipv4_29", Name: "IPv4 subnet /29", Type: "subnet_ipv4", Mask: 29, MonthlyNet: "13.6000"},
+}
+
+// AddonTransaction is an addon order placed with the fake. It completes like
+// a ServerTransaction, at which point the IP or subnet is added to the
+// server.
+type AddonTransaction struct {
+	ID           string
+	Date         string
+	Status       string
+	ServerNumber int
+	ProductID    string
+	Reason       string
+	// Resource is the allocated IP or network address.
+	Resource string
+	Test     bool
+	Cancel   bool
+	// Purged is as for ServerTransaction.
+	Purged  bool
+	pending int
+}
+
+// AddonTransactions is ServerTransactions for addon orders.
+func (f *Fake) AddonTransactions() []AddonTransaction {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	out := make([]AddonTransaction, 0, len(f.addonTransactions))
+	for _, tx := range f.addonTransactions {
+		out = append(out, *tx)
+	}
+	return out
+}
+
+// PurgeAddonTransaction makes an addon order answer 404 from now on.
+func (f *Fake) PurgeAddonTransaction(id string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, tx := range f.addonTransactions {
+		if tx.ID == id {
+			tx.Purged = true
+		}
+	}
+}
+
+func (f *Fake) registerAddonRoutes(mux *http.ServeMux) {
+	// Robot's /order/server_addon/{number}/product and
+	// /order/server_addon/transaction/{id} overlap as ServeMux patterns.
+	mux.HandleFunc("GET /order/server_addon/{number}/{id}", func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.PathValue("number") == "transaction":
+			f.getAddonTransaction(w, r)
+		case r.PathValue("id") == "product":
+			f.listAddonProducts(w, r)
+		default:
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "Not found")
+		}
+	})
+	mux.HandleFunc("POST /order/server_addon/transaction", f.orderAddon)
+}
+
+func findAddonProduct(id string) *AddonProduct {
+	for i := range AddonProducts {
+		if AddonProducts[i].ID == id {
+			return &AddonProducts[i]
+		}
+	}
+	return nil
+}
+
+func (p *AddonProduct) priceJSON(location string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "monthly",
+		"location":    location,
+		"price":       priceJSON(p.MonthlyNet),
+		"price_setup": priceJSON("0.0000"),
+	}
+}
+
+func (tx *AddonTransaction) transactionJSON() map[string]interface{} {
+	resources := []map[string]interface{}{}
+	if tx.Resource != "" {
+		resourceType := "ip"
+		if p := findAddonProduct(tx.ProductID); p != nil && p.Type != "ip_ipv4" {
+			resourceType = "subnet"
+		}
+		resources = append(resources, map[string]interface{}{"type": resourceType, "id": tx.Resource})
+	}
+	product := map[string]interface{}{"id": tx.ProductID}
+	if p := findAddonProduct(tx.ProductID); p != nil {
+		product["name"] = p.Name
+	}
+	return map[string]interface{}{
+		"id":            tx.ID,
+		"date":          tx.Date,
+		"status":        tx.Status,
+		"server_number": tx.ServerNumber,
+		"product":       product,
+		"resources":     resources,
+	}
+}
+
+func (f *Fake) listAddonProducts(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s := f.lookupServer(w, r)
+	if s == nil {
+		return
+	}
+	location, _, _ := strings.Cut(s.Datacenter, "-")
+	out := make([]map[string]interface{}, 0, len(AddonProducts))
+	for _, p := range AddonProducts {
+		out = append(out, map[string]interface{}{"product": map[string]interface{}{
+			"id":    p.ID,
+			"name":  p.Name,
+			"type":  p.Type,
+			"price": p.priceJSON(location),
+		}})
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (f *Fake) getAddonTransaction(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var tx *AddonTransaction
+	for _, t := range f.addonTransactions {
+		if t.ID == r.PathValue("id") && !t.Test && !t.Purged {
+			tx = t
+		}
+	}
+	if tx == nil {
+		writeError(w, http.StatusNotFound, "TRANSACTION_NOT_FOUND", "Transaction not found")
+		return
+	}
+	if tx.Status == "in process" {
+		f.advanceAddonTransaction(tx)
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"transaction": tx.transactionJSON()})
+}
+
+// advanceAddonTransaction is advanceServerTransaction for addon orders: a
+// completed order adds its IP or subnet to the server.
+func (f *Fake) advanceAddonTransaction(tx *AddonTransaction) {
+	if tx.pending > 0 {
+		tx.pending--
+		return
+	}
+	if tx.Cancel {
+		tx.Status = "cancelled"
+		return
+	}
+	s, ok := f.servers[tx.ServerNumber]
+	if !ok {
+		tx.Status = "cancelled"
+		return
+	}
+	f.nextAddonResource++
+	p := findAddonProduct(tx.ProductID)
+	if p.Type == "ip_ipv4" {
+		tx.Resource = fmt.Sprintf("192.0.2.%d", f.nextAddonResource%250+1)
+		s.IPs = append(s.IPs, tx.Resource)
+	} else {
+		tx.Resource = fmt.Sprintf("198.18.%d.0", f.nextAddonResource%250)
+		s.Subnets = append(s.Subnets, Subnet{IP: tx.Resource, Mask: strconv.Itoa(p.Mask)})
+	}
+	tx.Status = "ready"
+}
+
+func (f *Fake) orderAddon(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var missing []string
+	for _, key := range []string{"server_number", "product_id"} {
+		if r.Form.Get(key) == "" {
+			missing = append(missing, key)
+		}
+	}
+	p := findAddonProduct(r.Form.Get("product_id"))
+	if p != nil && p.Type == "ip_ipv4" && r.Form.Get("reason") == "" {
+		missing = append(missing, "reason")
+	}
+	if len(missing) > 0 {
+		writeInvalidInput(w, missing, nil)
+		return
+	}
+	number, err := strconv.Atoi(r.Form.Get("server_number"))
+	if _, ok := f.servers[number]; err != nil || !ok {
+		writeError(w, http.StatusNotFound, "SERVER_NOT_FOUND", "Server not found")
+		return
+	}
+	if p == nil {
+		writeError(w, http.StatusNotFound, "PRODUCT_NOT_FOUND", "Product not found")
+		return
+	}
+
+	f.nextTransactionID++
+	now := time.Now().UTC()
+	tx := &AddonTransaction{
+		ID:           fmt.Sprintf("B%s-%d-S%d", now.Format("20060102"), f.nextTransactionID, number),
+		Date:         now.Format(time.RFC3339),
+		Status:       "in process",
+		ServerNumber: number,
+		ProductID:    p.ID,
+		Reason:       r.Form.Get("reason"),
+		// Test orders are validated and answered like real ones, but
+		// never stored by Robot.
+		Test:    r.Form.Get("test") == "true",
+		pending: f.opts.PendingPolls,
+	}
+	f.addonTransactions = append(f.addonTransactions, tx)
+	writeJSON(w, http.StatusCreated, map[string]interface{}{"transaction": tx.transactionJSON()})
+}
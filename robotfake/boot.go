@@ -0,0 +1,198 @@
+package robotfake
+
+import (
+	"net/http"
+)
+
+// Rescue is the rescue system configuration of a server.
+type Rescue struct {
+	Active        bool
+	OS            string
+	Password      string
+	AuthorizedKey []string
+
+	// Booted is set once the server has been reset while the rescue system
+	// was active, i.e. when it would actually be running the rescue system.
+	Booted bool
+}
+
+// Reset is a reset request received by the fake.
+type Reset struct {
+	ServerNumber int
+	Type         string
+}
+
+// Rescue returns a copy of the rescue configuration of the given server.
+func (f *Fake) Rescue(server int) (Rescue, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	r, ok := f.rescues[server]
+	if !ok {
+		return Rescue{}, false
+	}
+	out := *r
+	out.AuthorizedKey = append([]string(nil), r.AuthorizedKey...)
+	return out, true
+}
+
+// Resets returns every reset executed so far, in order.
+func (f *Fake) Resets() []Reset {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Reset(nil), f.resets...)
+}
+
+var resetTypes = []string{"sw", "hw", "man", "power", "power_long"}
+
+func (f *Fake) registerBootRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /boot/{number}/rescue", f.getRescue)
+	mux.HandleFunc("POST /boot/{number}/rescue", f.enableRescue)
+	mux.HandleFunc("DELETE /boot/{number}/rescue", f.disableRescue)
+	mux.HandleFunc("GET /reset/{number}", f.getReset)
+	mux.HandleFunc("POST /reset/{number}", f.resetServer)
+}
+
+func rescueJSON(s *Server, r *Rescue) map[string]interface{} {
+	var password interface{}
+	if r.Active && r.Password != "" {
+		password = r.Password
+	}
+	var os interface{} = []string{"linux", "vkvm"}
+	if r.Active {
+		os = r.OS
+	}
+	keys := r.AuthorizedKey
+	if keys == nil {
+		keys = []string{}
+	}
+	return map[string]interface{}{
+		"rescue": map[string]interface{}{
+			"server_ip":       s.IP,
+			"server_ipv6_net": s.IPv6Net,
+			"server_number":   s.Number,
+			"os":              os,
+			"active":          r.Active,
+			"password":        password,
+			"authorized_key":  keys,
+			"host_key":        []string{},
+		},
+	}
+}
+
+func (f *Fake) rescueOf(s *Server) *Rescue {
+	r, ok := f.rescues[s.Number]
+	if !ok {
+		r = &Rescue{}
+		f.rescues[s.Number] = r
+	}
+	return r
+}
+
+func (f *Fake) getRescue(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s := f.lookupServer(w, r)
+	if s == nil {
+		return
+	}
+	writeJSON(w, http.StatusOK, rescueJSON(s, f.rescueOf(s)))
+}
+
+func (f *Fake) enableRescue(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s := f.lookupServer(w, r)
+	if s == nil {
+		return
+	}
+	os := r.Form.Get("os")
+	if os == "" {
+		writeInvalidInput(w, []string{"os"}, nil)
+		return
+	}
+	if os != "linux" && os != "vkvm" {
+		writeInvalidInput(w, nil, []string{"os"})
+		return
+	}
+	rescue := f.rescueOf(s)
+	if rescue.Active {
+		writeError(w, http.StatusConflict, "BOOT_ALREADY_ENABLED", "A boot option is already active")
+		return
+	}
+	*rescue = Rescue{
+		Active:        true,
+		OS:            os,
+		Password:      randomPassword(),
+		AuthorizedKey: r.Form["authorized_key[]"],
+	}
+	writeJSON(w, http.StatusOK, rescueJSON(s, rescue))
+}
+
+func (f *Fake) disableRescue(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s := f.lookupServer(w, r)
+	if s == nil {
+		return
+	}
+	rescue := f.rescueOf(s)
+	*rescue = Rescue{}
+	writeJSON(w, http.StatusOK, rescueJSON(s, rescue))
+}
+
+func (f *Fake) getReset(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s := f.lookupServer(w, r)
+	if s == nil {
+		return
+	}
+	if !s.Reset {
+		writeError(w, http.StatusNotFound, "RESET_NOT_AVAILABLE", "The server has no reset option")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"reset": map[string]interface{}{
+			"server_ip":        s.IP,
+			"server_ipv6_net":  s.IPv6Net,
+			"server_number":    s.Number,
+			"type":             resetTypes,
+			"operating_status": "not supported",
+		},
+	})
+}
+
+func (f *Fake) resetServer(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s := f.lookupServer(w, r)
+	if s == nil {
+		return
+	}
+	if !s.Reset {
+		writeError(w, http.StatusNotFound, "RESET_NOT_AVAILABLE", "The server has no reset option")
+		return
+	}
+	resetType := r.Form.Get("type")
+	valid := false
+	for _, t := range resetTypes {
+		if t == resetType {
+			valid = true
+		}
+	}
+	if !valid {
+		writeInvalidInput(w, nil, []string{"type"})
+		return
+	}
+	f.resets = append(f.resets, Reset{ServerNumber: s.Number, Type: resetType})
+	rescue := f.rescueOf(s)
+	rescue.Booted = rescue.Active
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"reset": map[string]interface{}{
+			"server_ip":       s.IP,
+			"server_ipv6_net": s.IPv6Net,
+			"server_number":   s.Number,
+			"type":            resetType,
+		},
+	})
+}
@@ -0,0 +1,134 @@
+package robotfake
+
+import (
+	"net/http"
+	"slices"
+	"time"
+)
+
+// CancellationReasons are the reasons the fake offers and accepts.
+var CancellationReasons = []string{
+	"Upgrade to a new server",
+	"Dissatisfied with the hardware",
+	"Dissatisfied with the support",
+	"Other",
+}
+
+func (s *Server) cancellationJSON() map[string]interface{} {
+	m := map[string]interface{}{
+		"server_ip":                  s.IP,
+		"server_ipv6_net":            s.IPv6Net,
+		"server_number":              s.Number,
+		"server_name":                s.Name,
+		"earliest_cancellation_date": s.EarliestCancellationDate,
+		"cancelled":                  s.Cancelled,
+		"reservation_possible":       true,
+		"reserved":                   s.Reserved,
+		"cancellation_date":          nullable(s.CancellationDate),
+		"cancellation_reason":        CancellationReasons,
+	}
+	if s.Cancelled {
+		m["cancellation_reason"] = nullable(s.CancellationReason)
+	}
+	return m
+}
+
+func (f *Fake) getCancellation(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s := f.lookupServer(w, r)
+	if s == nil {
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"cancellation": s.cancellationJSON()})
+}
+
+func (f *Fake) cancelServer(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s := f.lookupServer(w, r)
+	if s == nil {
+		return
+	}
+	if s.Cancelled {
+		writeError(w, http.StatusConflict, "CONFLICT", "The server is already cancelled")
+		return
+	}
+	date := r.Form.Get("cancellation_date")
+	if date == "" {
+		writeInvalidInput(w, []string{"cancellation_date"}, nil)
+		return
+	}
+	if date == "now" {
+		date = time.Now().UTC().Format(time.DateOnly)
+	} else if _, err := time.Parse(time.DateOnly, date); err != nil || date < s.EarliestCancellationDate {
+		writeInvalidInput(w, nil, []string{"cancellation_date"})
+		return
+	}
+	reason := r.Form.Get("cancellation_reason")
+	if reason != "" && !slices.Contains(CancellationReasons, reason) {
+		writeInvalidInput(w, nil, []string{"cancellation_reason"})
+		return
+	}
+	s.Cancelled = true
+	s.CancellationDate = date
+	s.CancellationReason = reason
+	s.Reserved = r.Form.Get("reserve_location") == "true"
+	writeJSON(w, http.StatusOK, map[string]interface{}{"cancellation": s.cancellationJSON()})
+}
+
+func (f *Fake) revokeCancellation(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s := f.lookupServer(w, r)
+	if s == nil {
+		return
+	}
+	if !s.Cancelled {
+		writeError(w, http.StatusConflict, "CONFLICT", "The server is not cancelled")
+		return
+	}
+	s.Cancelled = false
+	s.CancellationDate = ""
+	s.CancellationReason = ""
+	s.Reserved = false
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) reversalJSON() map[string]interface{} {
+	return map[string]interface{}{
+		"server_ip":         s.IP,
+		"server_ipv6_net":   s.IPv6Net,
+		"server_number":     s.Number,
+		"server_name":       s.Name,
+		"reversal_possible": s.ReversalPossible && !s.Reversed,
+		"reversed":          s.Reversed,
+		"reversal_reason":   nullable(s.ReversalReason),
+	}
+}
+
+func (f *Fake) getReversal(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s := f.lookupServer(w, r)
+	if s == nil {
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"reversal": s.reversalJSON()})
+}
+
+func (f *Fake) reverseServer(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s := f.lookupServer(w, r)
+	if s == nil {
+		return
+	}
+	if !s.ReversalPossible || s.Reversed {
+		writeError(w, http.StatusConflict, "CONFLICT", "The order of the server cannot be reversed")
+		return
+	}
+	s.Reversed = true
+	s.ReversalReason = r.Form.Get("reversal_reason")
+	writeJSON(w, http.StatusOK, map[string]interface{}{"reversal": s.reversalJSON()})
+}
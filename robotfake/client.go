@@ -0,0 +1,33 @@
+package robotfake
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/client"
+	"github.com/dd-hetzner-robot/terraform-provider-dd-hetzner-robot/shared"
+)
+
+// NewClient returns a client of the fake that retries and polls at test
+// speed. configure, if given, adjusts the configuration before the client is
+// created, e.g. to set MaxRetries or RescueSSHPort.
+func (f *Fake) NewClient(t testing.TB, configure ...func(*shared.ProviderConfig)) *client.HetznerRobotClient {
+	t.Helper()
+	config := &shared.ProviderConfig{
+		Username:        f.Username(),
+		Password:        f.Password(),
+		BaseURL:         f.URL(),
+		RetryWaitMin:    time.Millisecond,
+		RetryWaitMax:    5 * time.Millisecond,
+		PollMinInterval: time.Millisecond,
+		PollMaxInterval: 5 * time.Millisecond,
+	}
+	for _, fn := range configure {
+		fn(config)
+	}
+	c, err := client.NewHetznerRobotClient(config)
+	if err != nil {
+		t.Fatalf("NewHetznerRobotClient: %v", err)
+	}
+	return c
+}
@@ -0,0 +1,312 @@
+// Package robotfake implements an in-memory, stateful fake of the Hetzner
+// Robot webservice on top of net/http/httptest. Point the provider's url
+// setting (or shared.ProviderConfig.BaseURL) at Fake.URL to exercise
+// resources and data sources without touching robot-ws.your-server.de.
+package robotfake
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+const (
+	DefaultUsername = "robot-user"
+	DefaultPassword = "robot-password"
+)
+
+type Options struct {
+	Username string
+	Password string
+
+	// PendingPolls is the number of reads for which an asynchronous
+	// operation (vSwitch server assignment, firewall update) stays in
+	// progress before it completes.
+	PendingPolls int
+}
+
+// Request is a request received by the fake, as recorded by Requests.
+type Request struct {
+	Method    string
+	Path      string
+	Form      url.Values
+	UserAgent string
+}
+
+type injectedError struct {
+	method     string
+	path       string
+	remaining  int
+	status     int
+	code       string
+	message    string
+	retryAfter string
+}
+
+type Fake struct {
+	opts Options
+	srv  *httptest.Server
+
+	mu            sync.Mutex
+	servers       map[int]*Server
+	vswitches     map[int]*VSwitch
+	firewalls     map[int]*Firewall
+	rescues       map[int]*Rescue
+	resets        []Reset
+	nextVSwitchID int
+	requests      []Request
+	injected      []*injectedError
+	rateLimits    map[string]int
+	rateCounts    map[string]int
+
+	serverProducts     []*ServerProduct
+	marketProducts     []*MarketProduct
+	serverTransactions []*ServerTransaction
+	addonTransactions  []*AddonTransaction
+	nextServerNumber   int
+	nextTransactionID  int
+	nextAddonResource  int
+}
+
+// New starts a fake Robot webservice. Call Close when done.
+func New(opts Options) *Fake {
+	if opts.Username == "" {
+		opts.Username = DefaultUsername
+	}
+	if opts.Password == "" {
+		opts.Password = DefaultPassword
+	}
+	f := &Fake{
+		opts:          opts,
+		servers:       make(map[int]*Server),
+		vswitches:     make(map[int]*VSwitch),
+		firewalls:     make(map[int]*Firewall),
+		rescues:       make(map[int]*Rescue),
+		nextVSwitchID: 1000,
+		// Ordered servers get numbers well above those tests add by hand.
+		nextServerNumber:  2000000,
+		nextTransactionID: 340000,
+		rateLimits:        make(map[string]int),
+		rateCounts:        make(map[string]int),
+	}
+
+	mux := http.NewServeMux()
+	f.registerServerRoutes(mux)
+	f.registerVSwitchRoutes(mux)
+	f.registerFirewallRoutes(mux)
+	f.registerBootRoutes(mux)
+	f.registerOrderRoutes(mux)
+	f.registerMarketRoutes(mux)
+	f.registerAddonRoutes(mux)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "Not found")
+	})
+
+	f.srv = httptest.NewServer(f.middleware(mux))
+	return f
+}
+
+func (f *Fake) URL() string {
+	return f.srv.URL
+}
+
+func (f *Fake) Username() string {
+	return f.opts.Username
+}
+
+func (f *Fake) Password() string {
+	return f.opts.Password
+}
+
+func (f *Fake) Close() {
+	f.srv.Close()
+}
+
+// Requests returns every request received so far, in order.
+func (f *Fake) Requests() []Request {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Request(nil), f.requests...)
+}
+
+// FailNext makes the next count requests matching method and path (an exact
+// path, or a prefix ending in "/") fail with the given Robot error. An empty
+// method matches every method.
+func (f *Fake) FailNext(method, path string, count, status int, code, message string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.injected = append(f.injected, &injectedError{
+		method:    method,
+		path:      path,
+		remaining: count,
+		status:    status,
+		code:      code,
+		message:   message,
+	})
+}
+
+// RateLimitNext makes the next count requests matching method and path fail
+// with RATE_LIMIT_EXCEEDED. A non-empty retryAfter is sent as the
+// Retry-After header.
+func (f *Fake) RateLimitNext(method, path string, count int, retryAfter string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.injected = append(f.injected, &injectedError{
+		method:     method,
+		path:       path,
+		remaining:  count,
+		status:     http.StatusForbidden,
+		code:       "RATE_LIMIT_EXCEEDED",
+		message:    "Rate limit exceeded",
+		retryAfter: retryAfter,
+	})
+}
+
+// SetRateLimit limits the number of requests accepted for an endpoint family
+// (the first path segment, e.g. "server") over the lifetime of the fake.
+// Requests beyond the limit fail with RATE_LIMIT_EXCEEDED.
+func (f *Fake) SetRateLimit(family string, maxRequests int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rateLimits[family] = maxRequests
+}
+
+func (f *Fake) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		form := readForm(r)
+
+		f.mu.Lock()
+		f.requests = append(f.requests, Request{Method: r.Method, Path: r.URL.Path, Form: form, UserAgent: r.UserAgent()})
+		f.mu.Unlock()
+
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != f.opts.Username || pass != f.opts.Password {
+			writeError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized")
+			return
+		}
+
+		f.mu.Lock()
+		injected := f.takeInjected(r.Method, r.URL.Path)
+		limited := f.countRateLimit(r.URL.Path)
+		f.mu.Unlock()
+
+		if injected != nil {
+			if injected.retryAfter != "" {
+				w.Header().Set("Retry-After", injected.retryAfter)
+			}
+			writeError(w, injected.status, injected.code, injected.message)
+			return
+		}
+		if limited {
+			writeError(w, http.StatusForbidden, "RATE_LIMIT_EXCEEDED", "Rate limit exceeded")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (f *Fake) takeInjected(method, path string) *injectedError {
+	for i, inj := range f.injected {
+		if inj.method != "" && inj.method != method {
+			continue
+		}
+		if path != inj.path && !(strings.HasSuffix(inj.path, "/") && strings.HasPrefix(path, inj.path)) {
+			continue
+		}
+		inj.remaining--
+		if inj.remaining <= 0 {
+			f.injected = append(f.injected[:i], f.injected[i+1:]...)
+		}
+		return inj
+	}
+	return nil
+}
+
+func (f *Fake) countRateLimit(path string) bool {
+	family := strings.SplitN(strings.TrimPrefix(path, "/"), "/", 2)[0]
+	limit, ok := f.rateLimits[family]
+	if !ok {
+		return false
+	}
+	f.rateCounts[family]++
+	return f.rateCounts[family] > limit
+}
+
+// readForm parses the form body of any method. Robot accepts form bodies on
+// DELETE, which http.Request.ParseForm ignores.
+func readForm(r *http.Request) url.Values {
+	form := r.URL.Query()
+	r.Form = form
+	if r.Body == nil {
+		return form
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return form
+	}
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return form
+	}
+	for k, v := range values {
+		form[k] = append(form[k], v...)
+	}
+	return form
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, map[string]interface{}{
+		"error": map[string]interface{}{
+			"status":  status,
+			"code":    code,
+			"message": message,
+		},
+	})
+}
+
+func writeInvalidInput(w http.ResponseWriter, missing, invalid []string) {
+	writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+		"error": map[string]interface{}{
+			"status":  http.StatusBadRequest,
+			"code":    "INVALID_INPUT",
+			"message": "invalid input",
+			"missing": missing,
+			"invalid": invalid,
+		},
+	})
+}
+
+func randomPassword() string {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("robotfake: %v", err))
+	}
+	return hex.EncodeToString(b)
+}
+
+// ProviderConfig returns a provider block pointing the hetznerrobot provider
+// at the fake, for use in acceptance test configurations.
+func (f *Fake) ProviderConfig() string {
+	return fmt.Sprintf(`
+provider "hetznerrobot" {
+  url            = %q
+  username       = %q
+  password       = %q
+  retry_wait_min = 1
+  retry_wait_max = 1
+}
+`, f.URL(), f.opts.Username, f.opts.Password)
+}
@@ -0,0 +1,197 @@
+package robotfake
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// Firewall is the firewall of a server. After an update it reports
+// "in process" for Options.PendingPolls reads before the requested status
+// takes effect.
+type Firewall struct {
+	Status       string
+	WhitelistHOS bool
+	FilterIPv6   bool
+	Rules        []FirewallRule
+
+	pendingStatus string
+	pending       int
+}
+
+type FirewallRule struct {
+	IPVersion string
+	Name      string
+	DstIP     string
+	SrcIP     string
+	DstPort   string
+	SrcPort   string
+	Protocol  string
+	TCPFlags  string
+	Action    string
+}
+
+// Firewall returns a copy of the firewall of the given server.
+func (f *Fake) Firewall(server int) (Firewall, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	fw, ok := f.firewalls[server]
+	if !ok {
+		return Firewall{}, false
+	}
+	out := *fw
+	out.Rules = append([]FirewallRule(nil), fw.Rules...)
+	return out, true
+}
+
+func nullable(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+func firewallJSON(s *Server, fw *Firewall) map[string]interface{} {
+	rules := make([]map[string]interface{}, 0, len(fw.Rules))
+	for _, r := range fw.Rules {
+		rules = append(rules, map[string]interface{}{
+			"ip_version": nullable(r.IPVersion),
+			"name":       nullable(r.Name),
+			"dst_ip":     nullable(r.DstIP),
+			"src_ip":     nullable(r.SrcIP),
+			"dst_port":   nullable(r.DstPort),
+			"src_port":   nullable(r.SrcPort),
+			"protocol":   nullable(r.Protocol),
+			"tcp_flags":  nullable(r.TCPFlags),
+			"action":     r.Action,
+		})
+	}
+	return map[string]interface{}{
+		"firewall": map[string]interface{}{
+			"server_ip":     s.IP,
+			"server_number": s.Number,
+			"status":        fw.Status,
+			"filter_ipv6":   fw.FilterIPv6,
+			"whitelist_hos": fw.WhitelistHOS,
+			"port":          "main",
+			"rules": map[string]interface{}{
+				"input":  rules,
+				"output": []interface{}{},
+			},
+		},
+	}
+}
+
+func (f *Fake) registerFirewallRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /firewall/{server}", f.getFirewall)
+	mux.HandleFunc("POST /firewall/{server}", f.setFirewall)
+	mux.HandleFunc("DELETE /firewall/{server}", f.deleteFirewall)
+}
+
+// lookupFirewall resolves the {server} path value, which may be a server
+// number or main IP. f.mu must be held.
+func (f *Fake) lookupFirewall(w http.ResponseWriter, r *http.Request) (*Server, *Firewall) {
+	key := r.PathValue("server")
+	for _, s := range f.servers {
+		if s.IP == key || strconv.Itoa(s.Number) == key {
+			return s, f.firewalls[s.Number]
+		}
+	}
+	writeError(w, http.StatusNotFound, "SERVER_NOT_FOUND", "Server not found")
+	return nil, nil
+}
+
+func (fw *Firewall) advance() {
+	if fw.Status != "in process" {
+		return
+	}
+	if fw.pending > 0 {
+		fw.pending--
+		return
+	}
+	fw.Status = fw.pendingStatus
+}
+
+func (f *Fake) getFirewall(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s, fw := f.lookupFirewall(w, r)
+	if s == nil {
+		return
+	}
+	fw.advance()
+	writeJSON(w, http.StatusOK, firewallJSON(s, fw))
+}
+
+func (f *Fake) setFirewall(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s, fw := f.lookupFirewall(w, r)
+	if s == nil {
+		return
+	}
+	if fw.Status == "in process" {
+		writeError(w, http.StatusConflict, "FIREWALL_IN_PROCESS", "The firewall is being updated")
+		return
+	}
+	status := r.Form.Get("status")
+	if status != "active" && status != "disabled" {
+		writeInvalidInput(w, nil, []string{"status"})
+		return
+	}
+
+	var rules []FirewallRule
+	for i := 0; ; i++ {
+		prefix := fmt.Sprintf("rules[input][%d]", i)
+		action := r.Form.Get(prefix + "[action]")
+		if action == "" {
+			if _, ok := r.Form[prefix+"[name]"]; ok {
+				writeInvalidInput(w, []string{prefix + "[action]"}, nil)
+				return
+			}
+			break
+		}
+		if action != "accept" && action != "discard" {
+			writeInvalidInput(w, nil, []string{prefix + "[action]"})
+			return
+		}
+		rules = append(rules, FirewallRule{
+			IPVersion: r.Form.Get(prefix + "[ip_version]"),
+			Name:      r.Form.Get(prefix + "[name]"),
+			DstIP:     r.Form.Get(prefix + "[dst_ip]"),
+			SrcIP:     r.Form.Get(prefix + "[src_ip]"),
+			DstPort:   r.Form.Get(prefix + "[dst_port]"),
+			SrcPort:   r.Form.Get(prefix + "[src_port]"),
+			Protocol:  r.Form.Get(prefix + "[protocol]"),
+			TCPFlags:  r.Form.Get(prefix + "[tcp_flags]"),
+			Action:    action,
+		})
+	}
+
+	fw.WhitelistHOS = r.Form.Get("whitelist_hos") == "true"
+	fw.FilterIPv6 = r.Form.Get("filter_ipv6") == "true"
+	fw.Rules = rules
+	fw.pendingStatus = status
+	fw.pending = f.opts.PendingPolls
+	fw.Status = "in process"
+	writeJSON(w, http.StatusAccepted, firewallJSON(s, fw))
+}
+
+func (f *Fake) deleteFirewall(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s, fw := f.lookupFirewall(w, r)
+	if s == nil {
+		return
+	}
+	if fw.Status == "in process" {
+		writeError(w, http.StatusConflict, "FIREWALL_IN_PROCESS", "The firewall is being updated")
+		return
+	}
+	fw.Rules = nil
+	fw.WhitelistHOS = false
+	fw.pendingStatus = "disabled"
+	fw.pending = f.opts.PendingPolls
+	fw.Status = "in process"
+	writeJSON(w, http.StatusAccepted, firewallJSON(s, fw))
+}
@@ -0,0 +1,185 @@
+package robotfake
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+)
+
+// MarketProduct is a server offered on the server market of the fake. It is
+// removed from the market once it has been ordered.
+type MarketProduct struct {
+	ID           int
+	Name         string
+	Description  []string
+	Traffic      string
+	Dists        []string
+	Langs        []string
+	CPU          string
+	CPUBenchmark int
+	MemorySize   int
+	HDDSize      int
+	HDDCount     int
+	HDDText      string
+	Datacenter   string
+	// Price and PriceSetup are net prices such as "31.0000". Gross prices
+	// include 19% VAT.
+	Price      string
+	PriceSetup string
+	FixedPrice bool
+}
+
+// AddMarketProduct offers a server on the server market of the fake.
+func (f *Fake) AddMarketProduct(p MarketProduct) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if p.Traffic == "" {
+		p.Traffic = "unlimited"
+	}
+	if p.Langs == nil {
+		p.Langs = []string{"en"}
+	}
+	if p.PriceSetup == "" {
+		p.PriceSetup = "0.0000"
+	}
+	f.marketProducts = append(f.marketProducts, &p)
+}
+
+func (f *Fake) registerMarketRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /order/server_market/product", f.listMarketProducts)
+	mux.HandleFunc("GET /order/server_market/transaction/{id}", f.getMarketTransaction)
+	mux.HandleFunc("POST /order/server_market/transaction", f.orderMarketServer)
+}
+
+func (p *MarketProduct) productJSON() map[string]interface{} {
+	m := map[string]interface{}{
+		"id":               p.ID,
+		"name":             p.Name,
+		"description":      p.Description,
+		"traffic":          p.Traffic,
+		"dist":             p.Dists,
+		"arch":             []int{64},
+		"lang":             p.Langs,
+		"cpu":              p.CPU,
+		"cpu_benchmark":    p.CPUBenchmark,
+		"memory_size":      p.MemorySize,
+		"hdd_size":         p.HDDSize,
+		"hdd_text":         p.HDDText,
+		"hdd_count":        p.HDDCount,
+		"datacenter":       p.Datacenter,
+		"network_speed":    "1 Gbit/s",
+		"price":            p.Price,
+		"price_vat":        grossPrice(p.Price),
+		"price_setup":      p.PriceSetup,
+		"price_setup_vat":  grossPrice(p.PriceSetup),
+		"fixed_price":      p.FixedPrice,
+		"next_reduce":      0,
+		"next_reduce_date": nil,
+		"orderable_addons": []interface{}{},
+	}
+	if !p.FixedPrice {
+		m["next_reduce"] = 3600
+		m["next_reduce_date"] = "2030-01-01 12:00:00"
+	}
+	return m
+}
+
+func (f *Fake) findMarketProduct(id string) *MarketProduct {
+	number, err := strconv.Atoi(id)
+	if err != nil {
+		return nil
+	}
+	for _, p := range f.marketProducts {
+		if p.ID == number {
+			return p
+		}
+	}
+	return nil
+}
+
+func (f *Fake) listMarketProducts(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.marketProducts) == 0 {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "No products found")
+		return
+	}
+	out := make([]map[string]interface{}, 0, len(f.marketProducts))
+	for _, p := range f.marketProducts {
+		out = append(out, map[string]interface{}{"product": p.productJSON()})
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (f *Fake) getMarketTransaction(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	tx := f.findServerTransaction(r.PathValue("id"))
+	if tx == nil || tx.Test || tx.Purged || !tx.Market {
+		writeError(w, http.StatusNotFound, "TRANSACTION_NOT_FOUND", "Transaction not found")
+		return
+	}
+	if tx.Status == "in process" {
+		f.advanceServerTransaction(tx)
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"transaction": tx.transactionJSON()})
+}
+
+func (f *Fake) orderMarketServer(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	productID := r.Form.Get("product_id")
+	if productID == "" {
+		writeInvalidInput(w, []string{"product_id"}, nil)
+		return
+	}
+	p := f.findMarketProduct(productID)
+	if p == nil {
+		writeError(w, http.StatusNotFound, "PRODUCT_NOT_FOUND", "Product not found")
+		return
+	}
+	tx := &ServerTransaction{
+		ProductID:      productID,
+		Dist:           r.Form.Get("dist"),
+		Lang:           r.Form.Get("lang"),
+		Comment:        r.Form.Get("comment"),
+		Addons:         r.Form["addon[]"],
+		AuthorizedKeys: r.Form["authorized_key[]"],
+		Status:         "in process",
+		Market:         true,
+		pending:        f.opts.PendingPolls,
+	}
+	var invalid []string
+	if tx.Dist != "" && !slices.Contains(p.Dists, tx.Dist) {
+		invalid = append(invalid, "dist")
+	}
+	if tx.Lang == "" {
+		tx.Lang = p.Langs[0]
+	} else if !slices.Contains(p.Langs, tx.Lang) {
+		invalid = append(invalid, "lang")
+	}
+	if len(invalid) > 0 {
+		writeInvalidInput(w, nil, invalid)
+		return
+	}
+	tx.product = map[string]interface{}{
+		"id":            p.ID,
+		"name":          p.Name,
+		"description":   p.Description,
+		"traffic":       p.Traffic,
+		"dist":          tx.Dist,
+		"lang":          tx.Lang,
+		"cpu":           p.CPU,
+		"cpu_benchmark": p.CPUBenchmark,
+		"memory_size":   p.MemorySize,
+		"hdd_size":      p.HDDSize,
+		"hdd_text":      p.HDDText,
+		"hdd_count":     p.HDDCount,
+		"datacenter":    p.Datacenter,
+		"network_speed": "1 Gbit/s",
+	}
+	f.placeTransaction(w, r, tx)
+	if !tx.Test {
+		f.marketProducts = slices.DeleteFunc(f.marketProducts, func(m *MarketProduct) bool { return m == p })
+	}
+}
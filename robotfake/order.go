@@ -0,0 +1,314 @@
+package robotfake
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// ServerProduct is a product of the standard server catalog of the fake. It
+// costs the same in every location.
+type ServerProduct struct {
+	ID          string
+	Name        string
+	Description []string
+	Traffic     string
+	Dists       []string
+	Langs       []string
+	Locations   []string
+	// MonthlyNet and SetupNet are net prices such as "39.0000". Gross
+	// prices include 19% VAT.
+	MonthlyNet string
+	SetupNet   string
+}
+
+// ServerTransaction is a server order placed with the fake. It stays "in
+// process" for Options.PendingPolls reads and then becomes "ready", at which
+// point the server is added to the fake; if Cancel is set it becomes
+// "cancelled" instead. Test orders are recorded but cannot be read back.
+// Market is set for orders from the server market, whose ProductID is the
+// decimal product number.
+type ServerTransaction struct {
+	ID             string
+	Date           string
+	Status         string
+	ProductID      string
+	Location       string
+	Dist           string
+	Lang           string
+	Comment        string
+	Addons         []string
+	AuthorizedKeys []string
+	ServerNumber   int
+	ServerIP       string
+	Test           bool
+	Market         bool
+	Cancel         bool
+	// Purged transactions are no longer returned, as Robot forgets them
+	// about 30 days after the order.
+	Purged  bool
+	pending int
+	// product is the product part of the transaction JSON, taken when the
+	// order was placed.
+	product map[string]interface{}
+}
+
+// AddServerProduct adds a product to the catalog of the fake.
+func (f *Fake) AddServerProduct(p ServerProduct) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if p.Traffic == "" {
+		p.Traffic = "unlimited"
+	}
+	if p.Langs == nil {
+		p.Langs = []string{"en"}
+	}
+	f.serverProducts = append(f.serverProducts, &p)
+}
+
+// ServerTransactions returns a copy of every server order placed so far,
+// including test orders, in order.
+func (f *Fake) ServerTransactions() []ServerTransaction {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	out := make([]ServerTransaction, 0, len(f.serverTransactions))
+	for _, tx := range f.serverTransactions {
+		out = append(out, *tx)
+	}
+	return out
+}
+
+// CancelServerTransaction makes a pending server order end in "cancelled".
+func (f *Fake) CancelServerTransaction(id string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if tx := f.findServerTransaction(id); tx != nil {
+		tx.Cancel = true
+	}
+}
+
+// PurgeServerTransaction makes a server order answer 404 from now on.
+func (f *Fake) PurgeServerTransaction(id string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if tx := f.findServerTransaction(id); tx != nil {
+		tx.Purged = true
+	}
+}
+
+func (f *Fake) registerOrderRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /order/server/product", f.listServerProducts)
+	mux.HandleFunc("GET /order/server/transaction/{id}", f.getServerTransaction)
+	mux.HandleFunc("POST /order/server/transaction", f.orderServer)
+}
+
+// grossPrice adds 19% VAT to a net price.
+func grossPrice(net string) string {
+	v, err := strconv.ParseFloat(net, 64)
+	if err != nil {
+		return net
+	}
+	return strconv.FormatFloat(v*1.19, 'f', 4, 64)
+}
+
+func priceJSON(net string) map[string]interface{} {
+	return map[string]interface{}{"net": net, "gross": grossPrice(net)}
+}
+
+func (p *ServerProduct) productJSON() map[string]interface{} {
+	prices := make([]map[string]interface{}, 0, len(p.Locations))
+	for _, location := range p.Locations {
+		prices = append(prices, map[string]interface{}{
+			"location":    location,
+			"price":       priceJSON(p.MonthlyNet),
+			"price_setup": priceJSON(p.SetupNet),
+		})
+	}
+	return map[string]interface{}{
+		"id":               p.ID,
+		"name":             p.Name,
+		"description":      p.Description,
+		"traffic":          p.Traffic,
+		"dist":             p.Dists,
+		"arch":             []int{64},
+		"lang":             p.Langs,
+		"location":         p.Locations,
+		"prices":           prices,
+		"orderable_addons": []interface{}{},
+	}
+}
+
+func (tx *ServerTransaction) transactionJSON() map[string]interface{} {
+	var number interface{}
+	if tx.ServerNumber != 0 {
+		number = tx.ServerNumber
+	}
+	return map[string]interface{}{
+		"id":             tx.ID,
+		"date":           tx.Date,
+		"status":         tx.Status,
+		"server_number":  number,
+		"server_ip":      nullable(tx.ServerIP),
+		"authorized_key": []interface{}{},
+		"host_key":       []interface{}{},
+		"comment":        nullable(tx.Comment),
+		"product":        tx.product,
+		"addons":         tx.Addons,
+	}
+}
+
+func (f *Fake) findServerProduct(id string) *ServerProduct {
+	for _, p := range f.serverProducts {
+		if p.ID == id {
+			return p
+		}
+	}
+	return nil
+}
+
+func (f *Fake) findServerTransaction(id string) *ServerTransaction {
+	for _, tx := range f.serverTransactions {
+		if tx.ID == id {
+			return tx
+		}
+	}
+	return nil
+}
+
+func (f *Fake) listServerProducts(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.serverProducts) == 0 {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "No products found")
+		return
+	}
+	out := make([]map[string]interface{}, 0, len(f.serverProducts))
+	for _, p := range f.serverProducts {
+		out = append(out, map[string]interface{}{"product": p.productJSON()})
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (f *Fake) getServerTransaction(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	tx := f.findServerTransaction(r.PathValue("id"))
+	if tx == nil || tx.Test || tx.Purged || tx.Market {
+		writeError(w, http.StatusNotFound, "TRANSACTION_NOT_FOUND", "Transaction not found")
+		return
+	}
+	if tx.Status == "in process" {
+		f.advanceServerTransaction(tx)
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"transaction": tx.transactionJSON()})
+}
+
+// advanceServerTransaction counts a read of a pending transaction and
+// completes it once Options.PendingPolls reads have passed. f.mu must be
+// held.
+func (f *Fake) advanceServerTransaction(tx *ServerTransaction) {
+	if tx.pending > 0 {
+		tx.pending--
+		return
+	}
+	if tx.Cancel {
+		tx.Status = "cancelled"
+		return
+	}
+	f.nextServerNumber++
+	tx.ServerNumber = f.nextServerNumber
+	tx.ServerIP = fmt.Sprintf("198.51.100.%d", f.nextServerNumber%250+1)
+	tx.Status = "ready"
+	datacenter, _ := tx.product["datacenter"].(string)
+	if datacenter == "" {
+		datacenter = tx.Location + "-DC1"
+	}
+	s := &Server{
+		Number:     tx.ServerNumber,
+		IP:         tx.ServerIP,
+		IPs:        []string{tx.ServerIP},
+		Product:    tx.product["name"].(string),
+		Datacenter: datacenter,
+		Traffic:    "unlimited",
+		Status:     "ready",
+		PaidUntil:  time.Now().UTC().AddDate(0, 1, 0).Format(time.DateOnly),
+		Reset:      true,
+		Rescue:     true,
+		// A new server can be returned during the withdrawal period.
+		ReversalPossible: true,
+	}
+	s.EarliestCancellationDate = s.PaidUntil
+	f.servers[s.Number] = s
+	f.firewalls[s.Number] = &Firewall{Status: "disabled"}
+}
+
+func (f *Fake) orderServer(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	productID := r.Form.Get("product_id")
+	if productID == "" {
+		writeInvalidInput(w, []string{"product_id"}, nil)
+		return
+	}
+	p := f.findServerProduct(productID)
+	if p == nil {
+		writeError(w, http.StatusNotFound, "PRODUCT_NOT_FOUND", "Product not found")
+		return
+	}
+	tx := &ServerTransaction{
+		ProductID:      p.ID,
+		Location:       r.Form.Get("location"),
+		Dist:           r.Form.Get("dist"),
+		Lang:           r.Form.Get("lang"),
+		Comment:        r.Form.Get("comment"),
+		Addons:         r.Form["addon[]"],
+		AuthorizedKeys: r.Form["authorized_key[]"],
+		Status:         "in process",
+		pending:        f.opts.PendingPolls,
+	}
+	var invalid []string
+	switch {
+	case tx.Location == "" && len(p.Locations) == 1:
+		tx.Location = p.Locations[0]
+	case !slices.Contains(p.Locations, tx.Location):
+		invalid = append(invalid, "location")
+	}
+	if tx.Dist != "" && !slices.Contains(p.Dists, tx.Dist) {
+		invalid = append(invalid, "dist")
+	}
+	if tx.Lang == "" {
+		tx.Lang = p.Langs[0]
+	} else if !slices.Contains(p.Langs, tx.Lang) {
+		invalid = append(invalid, "lang")
+	}
+	if len(invalid) > 0 {
+		writeInvalidInput(w, nil, invalid)
+		return
+	}
+	tx.product = map[string]interface{}{
+		"id":          p.ID,
+		"name":        p.Name,
+		"description": p.Description,
+		"traffic":     p.Traffic,
+		"dist":        tx.Dist,
+		"lang":        tx.Lang,
+		"location":    tx.Location,
+	}
+	f.placeTransaction(w, r, tx)
+}
+
+// placeTransaction assigns an ID to a validated order, records it and
+// answers the request. f.mu must be held.
+func (f *Fake) placeTransaction(w http.ResponseWriter, r *http.Request, tx *ServerTransaction) {
+	f.nextTransactionID++
+	now := time.Now().UTC()
+	tx.ID = fmt.Sprintf("B%s-%d", now.Format("20060102"), f.nextTransactionID)
+	tx.Date = now.Format(time.RFC3339)
+	// Test orders are validated and answered like real ones, but never
+	// stored by Robot.
+	tx.Test = r.Form.Get("test") == "true"
+	f.serverTransactions = append(f.serverTransactions, tx)
+	writeJSON(w, http.StatusCreated, map[string]interface{}{"transaction": tx.transactionJSON()})
+}
@@ -0,0 +1,412 @@
+package robotfake
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// Disk is a block device of the emulated rescue system.
+type Disk struct {
+	Name string
+
+	// Wiped is set once the disk has been wiped and cleared again when an
+	// image is written to it.
+	Wiped bool
+	// Image is the URL of the image written to the disk, if any.
+	Image string
+}
+
+// RescueSSH is an in-process SSH server emulating the rescue system of a
+// dedicated server. It understands the commands run by the OS installer,
+// records them and applies them to a set of simulated disks. Anything else
+// fails with exit status 127.
+type RescueSSH struct {
+	listener net.Listener
+	config   *ssh.ServerConfig
+	onReboot func(server int)
+
+	mu       sync.Mutex
+	disks    []*Disk
+	files    map[string]string
+	commands []string
+	failures []*commandFailure
+	reboots  int
+	conns    map[net.Conn]struct{}
+	closed   bool
+	wg       sync.WaitGroup
+}
+
+type commandFailure struct {
+	substring  string
+	remaining  int
+	exitStatus int
+	output     string
+}
+
+const serverNumberExtension = "server-number"
+
+// NewRescueSSH starts a rescue system that accepts root logins with the given
+// password. Without disks it has two NVMe disks. Call Close when done.
+func NewRescueSSH(password string, disks ...string) (*RescueSSH, error) {
+	auth := func(pass string) (int, bool) { return 0, pass == password }
+	return newRescueSSH(auth, nil, disks)
+}
+
+// StartRescueSSH starts a rescue system for the servers of the fake. It
+// accepts the rescue password of any server that has been reset into an
+// active rescue system; running reboot leaves the rescue system of that
+// server, like on real hardware. Call Close on the result when done.
+func (f *Fake) StartRescueSSH(disks ...string) (*RescueSSH, error) {
+	auth := func(pass string) (int, bool) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		for number, r := range f.rescues {
+			if r.Booted && r.Password != "" && r.Password == pass {
+				return number, true
+			}
+		}
+		return 0, false
+	}
+	onReboot := func(server int) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if r, ok := f.rescues[server]; ok {
+			*r = Rescue{}
+		}
+	}
+	return newRescueSSH(auth, onReboot, disks)
+}
+
+func newRescueSSH(auth func(password string) (int, bool), onReboot func(int), disks []string) (*RescueSSH, error) {
+	if len(disks) == 0 {
+		disks = []string{"nvme0n1", "nvme1n1"}
+	}
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		return nil, err
+	}
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(meta ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			server, ok := auth(string(password))
+			if meta.User() != "root" || !ok {
+				return nil, fmt.Errorf("permission denied for %s", meta.User())
+			}
+			return &ssh.Permissions{Extensions: map[string]string{serverNumberExtension: strconv.Itoa(server)}}, nil
+		},
+	}
+	config.AddHostKey(signer)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	s := &RescueSSH{
+		listener: listener,
+		config:   config,
+		onReboot: onReboot,
+		files:    make(map[string]string),
+		conns:    make(map[net.Conn]struct{}),
+	}
+	for _, name := range disks {
+		s.disks = append(s.disks, &Disk{Name: name})
+	}
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+// Host returns the address the server listens on.
+func (s *RescueSSH) Host() string {
+	return s.listener.Addr().(*net.TCPAddr).IP.String()
+}
+
+// Port returns the port the server listens on, for use as the provider's
+// rescue_ssh_port.
+func (s *RescueSSH) Port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+// Close stops the server and drops all connections.
+func (s *RescueSSH) Close() {
+	s.mu.Lock()
+	s.closed = true
+	for c := range s.conns {
+		c.Close()
+	}
+	s.mu.Unlock()
+	s.listener.Close()
+	s.wg.Wait()
+}
+
+// Commands returns every command executed so far, in order.
+func (s *RescueSSH) Commands() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.commands...)
+}
+
+// Disks returns a copy of the simulated disks.
+func (s *RescueSSH) Disks() []Disk {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]Disk, 0, len(s.disks))
+	for _, d := range s.disks {
+		out = append(out, *d)
+	}
+	return out
+}
+
+// Reboots returns the number of times reboot has been run.
+func (s *RescueSSH) Reboots() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.reboots
+}
+
+// FailNextCommand makes the next count commands containing substring exit
+// with exitStatus after printing output. A trailing "|| true" still turns the
+// failure into success, as in a shell.
+func (s *RescueSSH) FailNextCommand(substring string, count, exitStatus int, output string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = append(s.failures, &commandFailure{
+		substring:  substring,
+		remaining:  count,
+		exitStatus: exitStatus,
+		output:     output,
+	})
+}
+
+func (s *RescueSSH) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			conn.Close()
+			return
+		}
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handleConn(conn)
+			s.mu.Lock()
+			delete(s.conns, conn)
+			s.mu.Unlock()
+			conn.Close()
+		}()
+	}
+}
+
+func (s *RescueSSH) handleConn(netConn net.Conn) {
+	conn, chans, reqs, err := ssh.NewServerConn(netConn, s.config)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	go ssh.DiscardRequests(reqs)
+
+	server, _ := strconv.Atoi(conn.Permissions.Extensions[serverNumberExtension])
+	for newChannel := range chans {
+		if newChannel.ChannelType() != "session" {
+			_ = newChannel.Reject(ssh.UnknownChannelType, "only sessions are supported")
+			continue
+		}
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			return
+		}
+		go s.handleSession(conn, server, channel, requests)
+	}
+}
+
+func (s *RescueSSH) handleSession(conn *ssh.ServerConn, server int, channel ssh.Channel, requests <-chan *ssh.Request) {
+	defer channel.Close()
+	for req := range requests {
+		if req.Type != "exec" {
+			_ = req.Reply(false, nil)
+			continue
+		}
+		var payload struct{ Command string }
+		if err := ssh.Unmarshal(req.Payload, &payload); err != nil {
+			_ = req.Reply(false, nil)
+			continue
+		}
+		_ = req.Reply(true, nil)
+
+		output, status, reboot := s.exec(payload.Command)
+		if reboot {
+			// The machine goes down before the command can report back.
+			if s.onReboot != nil {
+				s.onReboot(server)
+			}
+			conn.Close()
+			return
+		}
+		_, _ = channel.Write([]byte(output))
+		_, _ = channel.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{uint32(status)}))
+		return
+	}
+}
+
+// exec runs a command line and returns its output and exit status.
+func (s *RescueSSH) exec(line string) (string, int, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.commands = append(s.commands, line)
+
+	cmd, ignoreFailure := strings.CutSuffix(strings.TrimSpace(line), "|| true")
+	cmd = strings.TrimSpace(cmd)
+
+	output, status, reboot := s.injectedFailure(line)
+	if status == 0 {
+		output, status, reboot = s.run(strings.Fields(cmd))
+	}
+	if ignoreFailure {
+		status = 0
+	}
+	return output, status, reboot
+}
+
+func (s *RescueSSH) injectedFailure(line string) (string, int, bool) {
+	for i, f := range s.failures {
+		if !strings.Contains(line, f.substring) {
+			continue
+		}
+		f.remaining--
+		if f.remaining <= 0 {
+			s.failures = append(s.failures[:i], s.failures[i+1:]...)
+		}
+		return f.output, f.exitStatus, false
+	}
+	return "", 0, false
+}
+
+func (s *RescueSSH) disk(path string) *Disk {
+	for _, d := range s.disks {
+		if "/dev/"+d.Name == path {
+			return d
+		}
+	}
+	return nil
+}
+
+// checkDevices fails for any /dev path among args that is neither a disk nor
+// a pseudo device.
+func (s *RescueSSH) checkDevices(args []string) (string, int) {
+	for _, arg := range args {
+		if _, value, ok := strings.Cut(arg, "="); ok {
+			arg = value
+		}
+		if !strings.HasPrefix(arg, "/dev/") || arg == "/dev/zero" || arg == "/dev/md0" {
+			continue
+		}
+		if s.disk(arg) == nil {
+			return fmt.Sprintf("%s: No such file or directory\n", arg), 1
+		}
+	}
+	return "", 0
+}
+
+func flagValue(args []string, flag string) string {
+	for i, arg := range args {
+		if arg == flag && i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+	return ""
+}
+
+func (s *RescueSSH) run(args []string) (string, int, bool) {
+	if len(args) == 0 {
+		return "", 0, false
+	}
+	if out, status := s.checkDevices(args[1:]); status != 0 {
+		return out, status, false
+	}
+	switch args[0] {
+	case "lsblk":
+		var b strings.Builder
+		for _, d := range s.disks {
+			fmt.Fprintf(&b, "%s disk\n", d.Name)
+		}
+		return b.String(), 0, false
+	case "mdadm", "sync":
+		return "", 0, false
+	case "wipefs":
+		if d := s.disk(args[len(args)-1]); d != nil {
+			d.Wiped = true
+			d.Image = ""
+		}
+		return "", 0, false
+	case "wget":
+		target := flagValue(args, "-O")
+		if target == "" || len(args) < 2 {
+			return "wget: missing URL\n", 1, false
+		}
+		s.files[target] = args[1]
+		return "", 0, false
+	case "zstd":
+		src, dst := "", flagValue(args, "-o")
+		for _, arg := range args[1:] {
+			if !strings.HasPrefix(arg, "-") && arg != dst {
+				src = arg
+			}
+		}
+		image, ok := s.files[src]
+		if !ok {
+			return fmt.Sprintf("zstd: %s: No such file or directory\n", src), 1, false
+		}
+		s.files[dst] = image
+		return "", 0, false
+	case "dd":
+		var in, out string
+		for _, arg := range args[1:] {
+			if v, ok := strings.CutPrefix(arg, "if="); ok {
+				in = v
+			}
+			if v, ok := strings.CutPrefix(arg, "of="); ok {
+				out = v
+			}
+		}
+		d := s.disk(out)
+		if d == nil {
+			return fmt.Sprintf("dd: failed to open '%s': No such file or directory\n", out), 1, false
+		}
+		if in == "/dev/zero" {
+			d.Wiped = true
+			d.Image = ""
+			return "", 0, false
+		}
+		image, ok := s.files[in]
+		if !ok {
+			return fmt.Sprintf("dd: failed to open '%s': No such file or directory\n", in), 1, false
+		}
+		d.Wiped = false
+		d.Image = image
+		return "", 0, false
+	case "reboot":
+		s.reboots++
+		return "", 0, true
+	default:
+		return fmt.Sprintf("bash: %s: command not found\n", args[0]), 127, false
+	}
+}
@@ -0,0 +1,200 @@
+package robotfake
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+type Subnet struct {
+	IP   string `json:"ip"`
+	Mask string `json:"mask"`
+}
+
+// Server is a dedicated server known to the fake.
+type Server struct {
+	Number     int
+	Name       string
+	IP         string
+	IPv6Net    string
+	Product    string
+	Datacenter string
+	Traffic    string
+	Status     string
+	Cancelled  bool
+	PaidUntil  string
+	IPs        []string
+	Subnets    []Subnet
+
+	Reset            bool
+	Rescue           bool
+	VNC              bool
+	Windows          bool
+	Plesk            bool
+	CPanel           bool
+	WOL              bool
+	HotSwap          bool
+	LinkedStoragebox *int
+
+	// EarliestCancellationDate defaults to PaidUntil.
+	EarliestCancellationDate string
+	CancellationDate         string
+	CancellationReason       string
+	Reserved                 bool
+
+	// ReversalPossible marks a freshly ordered server that is still within
+	// the withdrawal period.
+	ReversalPossible bool
+	Reversed         bool
+	ReversalReason   string
+}
+
+// AddServer adds a server to the fake. Unset descriptive fields get plausible
+// defaults; capability flags such as Reset are taken as given.
+func (f *Fake) AddServer(s Server) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if s.Status == "" {
+		s.Status = "ready"
+	}
+	if s.Product == "" {
+		s.Product = "AX41-NVMe"
+	}
+	if s.Datacenter == "" {
+		s.Datacenter = "FSN1-DC1"
+	}
+	if s.Traffic == "" {
+		s.Traffic = "unlimited"
+	}
+	if s.PaidUntil == "" {
+		s.PaidUntil = "2030-01-01"
+	}
+	if s.EarliestCancellationDate == "" {
+		s.EarliestCancellationDate = s.PaidUntil
+	}
+	if s.IPs == nil && s.IP != "" {
+		s.IPs = []string{s.IP}
+	}
+	if s.Subnets == nil && s.IPv6Net != "" {
+		s.Subnets = []Subnet{{IP: s.IPv6Net, Mask: "64"}}
+	}
+	f.servers[s.Number] = &s
+	f.firewalls[s.Number] = &Firewall{Status: "disabled"}
+}
+
+// Server returns a copy of the server with the given number.
+func (f *Fake) Server(number int) (Server, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s, ok := f.servers[number]
+	if !ok {
+		return Server{}, false
+	}
+	return *s, true
+}
+
+func (s *Server) summaryJSON() map[string]interface{} {
+	return map[string]interface{}{
+		"server_ip":       s.IP,
+		"server_ipv6_net": s.IPv6Net,
+		"server_number":   s.Number,
+		"server_name":     s.Name,
+		"product":         s.Product,
+		"dc":              s.Datacenter,
+		"traffic":         s.Traffic,
+		"status":          s.Status,
+		"cancelled":       s.Cancelled,
+		"paid_until":      s.PaidUntil,
+		"ip":              s.IPs,
+		"subnet":          s.Subnets,
+	}
+}
+
+func (s *Server) detailJSON() map[string]interface{} {
+	m := s.summaryJSON()
+	m["reset"] = s.Reset
+	m["rescue"] = s.Rescue
+	m["vnc"] = s.VNC
+	m["windows"] = s.Windows
+	m["plesk"] = s.Plesk
+	m["cpanel"] = s.CPanel
+	m["wol"] = s.WOL
+	m["hot_swap"] = s.HotSwap
+	m["linked_storagebox"] = s.LinkedStoragebox
+	return m
+}
+
+func (f *Fake) registerServerRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /server", f.listServers)
+	mux.HandleFunc("GET /server/{number}", f.getServer)
+	mux.HandleFunc("POST /server/{number}", f.updateServer)
+	mux.HandleFunc("GET /server/{number}/cancellation", f.getCancellation)
+	mux.HandleFunc("POST /server/{number}/cancellation", f.cancelServer)
+	mux.HandleFunc("DELETE /server/{number}/cancellation", f.revokeCancellation)
+	mux.HandleFunc("GET /server/{number}/reversal", f.getReversal)
+	mux.HandleFunc("POST /server/{number}/reversal", f.reverseServer)
+}
+
+// lookupServer resolves the {number} path value. It writes the Robot error
+// and returns nil if there is no such server. f.mu must be held.
+func (f *Fake) lookupServer(w http.ResponseWriter, r *http.Request) *Server {
+	number, err := strconv.Atoi(r.PathValue("number"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, "SERVER_NOT_FOUND", "Server not found")
+		return nil
+	}
+	s, ok := f.servers[number]
+	if !ok {
+		writeError(w, http.StatusNotFound, "SERVER_NOT_FOUND", "Server not found")
+		return nil
+	}
+	return s
+}
+
+func (f *Fake) sortedServers() []*Server {
+	servers := make([]*Server, 0, len(f.servers))
+	for _, s := range f.servers {
+		servers = append(servers, s)
+	}
+	sort.Slice(servers, func(i, j int) bool { return servers[i].Number < servers[j].Number })
+	return servers
+}
+
+func (f *Fake) listServers(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.servers) == 0 {
+		writeError(w, http.StatusNotFound, "SERVER_NOT_FOUND", "No servers found")
+		return
+	}
+	var out []map[string]interface{}
+	for _, s := range f.sortedServers() {
+		out = append(out, map[string]interface{}{"server": s.summaryJSON()})
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (f *Fake) getServer(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s := f.lookupServer(w, r)
+	if s == nil {
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"server": s.detailJSON()})
+}
+
+func (f *Fake) updateServer(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s := f.lookupServer(w, r)
+	if s == nil {
+		return
+	}
+	if _, ok := r.Form["server_name"]; !ok {
+		writeInvalidInput(w, []string{"server_name"}, nil)
+		return
+	}
+	s.Name = r.Form.Get("server_name")
+	writeJSON(w, http.StatusOK, map[string]interface{}{"server": s.detailJSON()})
+}
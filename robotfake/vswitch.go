@@ -0,0 +1,339 @@
+package robotfake
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+// VSwitch is a vSwitch known to the fake.
+type VSwitch struct {
+	ID        int
+	Name      string
+	VLAN      int
+	Cancelled bool
+	Servers   []*VSwitchServer
+}
+
+// VSwitchServer is a server attachment of a vSwitch. It stays "processing"
+// for Options.PendingPolls reads of the vSwitch and then becomes "ready",
+// unless Fail is set, in which case it becomes "failed".
+type VSwitchServer struct {
+	Number  int
+	Status  string
+	Fail    bool
+	pending int
+}
+
+// VSwitch returns a copy of the vSwitch with the given ID.
+func (f *Fake) VSwitch(id int) (VSwitch, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	v, ok := f.vswitches[id]
+	if !ok {
+		return VSwitch{}, false
+	}
+	out := *v
+	out.Servers = nil
+	for _, s := range v.Servers {
+		c := *s
+		out.Servers = append(out.Servers, &c)
+	}
+	return out, true
+}
+
+// FailVSwitchServer makes the next assignment of server to a vSwitch end in
+// the "failed" state.
+func (f *Fake) FailVSwitchServer(vswitchID, server int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if v, ok := f.vswitches[vswitchID]; ok {
+		for _, s := range v.Servers {
+			if s.Number == server {
+				s.Fail = true
+			}
+		}
+	}
+}
+
+func (v *VSwitch) inProcess() bool {
+	for _, s := range v.Servers {
+		if s.Status == "processing" {
+			return true
+		}
+	}
+	return false
+}
+
+// advance moves processing server assignments one read closer to completion.
+func (v *VSwitch) advance() {
+	for _, s := range v.Servers {
+		if s.Status != "processing" {
+			continue
+		}
+		if s.pending > 0 {
+			s.pending--
+			continue
+		}
+		if s.Fail {
+			s.Status = "failed"
+		} else {
+			s.Status = "ready"
+		}
+	}
+}
+
+func (f *Fake) vswitchJSON(v *VSwitch) map[string]interface{} {
+	servers := make([]map[string]interface{}, 0, len(v.Servers))
+	for _, vs := range v.Servers {
+		entry := map[string]interface{}{
+			"server_number": vs.Number,
+			"status":        vs.Status,
+		}
+		if s, ok := f.servers[vs.Number]; ok {
+			entry["server_ip"] = s.IP
+			entry["server_ipv6_net"] = s.IPv6Net
+		}
+		servers = append(servers, entry)
+	}
+	return map[string]interface{}{
+		"id":            v.ID,
+		"name":          v.Name,
+		"vlan":          v.VLAN,
+		"cancelled":     v.Cancelled,
+		"server":        servers,
+		"subnet":        []interface{}{},
+		"cloud_network": []interface{}{},
+	}
+}
+
+func (f *Fake) registerVSwitchRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /vswitch", f.listVSwitches)
+	mux.HandleFunc("POST /vswitch", f.createVSwitch)
+	mux.HandleFunc("GET /vswitch/{id}", f.getVSwitch)
+	mux.HandleFunc("POST /vswitch/{id}", f.updateVSwitch)
+	mux.HandleFunc("DELETE /vswitch/{id}", f.deleteVSwitch)
+	mux.HandleFunc("POST /vswitch/{id}/server", f.addVSwitchServers)
+	mux.HandleFunc("DELETE /vswitch/{id}/server", f.removeVSwitchServers)
+}
+
+// lookupVSwitch resolves the {id} path value. It writes the Robot error and
+// returns nil if there is no such vSwitch. f.mu must be held.
+func (f *Fake) lookupVSwitch(w http.ResponseWriter, r *http.Request) *VSwitch {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err == nil {
+		if v, ok := f.vswitches[id]; ok {
+			return v
+		}
+	}
+	writeError(w, http.StatusNotFound, "NOT_FOUND", "vSwitch not found")
+	return nil
+}
+
+func (f *Fake) vlanTaken(vlan, exceptID int) bool {
+	for _, v := range f.vswitches {
+		if v.ID != exceptID && v.VLAN == vlan && !v.Cancelled {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *Fake) listVSwitches(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ids := make([]int, 0, len(f.vswitches))
+	for id := range f.vswitches {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	out := make([]map[string]interface{}, 0, len(ids))
+	for _, id := range ids {
+		v := f.vswitches[id]
+		out = append(out, map[string]interface{}{
+			"id":        v.ID,
+			"name":      v.Name,
+			"vlan":      v.VLAN,
+			"cancelled": v.Cancelled,
+		})
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (f *Fake) createVSwitch(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var missing, invalid []string
+	name := r.Form.Get("name")
+	if name == "" {
+		missing = append(missing, "name")
+	}
+	vlan, err := strconv.Atoi(r.Form.Get("vlan"))
+	if r.Form.Get("vlan") == "" {
+		missing = append(missing, "vlan")
+	} else if err != nil || vlan < 4000 || vlan > 4091 {
+		invalid = append(invalid, "vlan")
+	}
+	if len(missing) > 0 || len(invalid) > 0 {
+		writeInvalidInput(w, missing, invalid)
+		return
+	}
+	if f.vlanTaken(vlan, 0) {
+		writeError(w, http.StatusConflict, "VSWITCH_VLAN_NOT_UNIQUE", "VLAN already in use")
+		return
+	}
+	f.nextVSwitchID++
+	v := &VSwitch{ID: f.nextVSwitchID, Name: name, VLAN: vlan}
+	f.vswitches[v.ID] = v
+	writeJSON(w, http.StatusCreated, f.vswitchJSON(v))
+}
+
+func (f *Fake) getVSwitch(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	v := f.lookupVSwitch(w, r)
+	if v == nil {
+		return
+	}
+	v.advance()
+	writeJSON(w, http.StatusOK, f.vswitchJSON(v))
+}
+
+func (f *Fake) updateVSwitch(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	v := f.lookupVSwitch(w, r)
+	if v == nil {
+		return
+	}
+	if v.inProcess() {
+		writeError(w, http.StatusConflict, "VSWITCH_IN_PROCESS", "There is an update running for this vSwitch")
+		return
+	}
+	if name := r.Form.Get("name"); name != "" {
+		v.Name = name
+	}
+	if raw := r.Form.Get("vlan"); raw != "" {
+		vlan, err := strconv.Atoi(raw)
+		if err != nil || vlan < 4000 || vlan > 4091 {
+			writeInvalidInput(w, nil, []string{"vlan"})
+			return
+		}
+		if f.vlanTaken(vlan, v.ID) {
+			writeError(w, http.StatusConflict, "VSWITCH_VLAN_NOT_UNIQUE", "VLAN already in use")
+			return
+		}
+		if vlan != v.VLAN {
+			v.VLAN = vlan
+			for _, s := range v.Servers {
+				s.Status = "processing"
+				s.pending = f.opts.PendingPolls
+			}
+		}
+	}
+	writeJSON(w, http.StatusCreated, f.vswitchJSON(v))
+}
+
+func (f *Fake) deleteVSwitch(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	v := f.lookupVSwitch(w, r)
+	if v == nil {
+		return
+	}
+	if v.inProcess() {
+		writeError(w, http.StatusConflict, "VSWITCH_IN_PROCESS", "There is an update running for this vSwitch")
+		return
+	}
+	date := r.Form.Get("cancellation_date")
+	if date == "" {
+		writeInvalidInput(w, []string{"cancellation_date"}, nil)
+		return
+	}
+	if date == "now" {
+		delete(f.vswitches, v.ID)
+	} else {
+		v.Cancelled = true
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (f *Fake) formServers(w http.ResponseWriter, r *http.Request) ([]int, bool) {
+	raw := r.Form["server[]"]
+	if len(raw) == 0 {
+		writeInvalidInput(w, []string{"server"}, nil)
+		return nil, false
+	}
+	var numbers []int
+	for _, s := range raw {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			writeInvalidInput(w, nil, []string{"server"})
+			return nil, false
+		}
+		if _, ok := f.servers[n]; !ok {
+			writeError(w, http.StatusNotFound, "SERVER_NOT_FOUND", "Server not found")
+			return nil, false
+		}
+		numbers = append(numbers, n)
+	}
+	return numbers, true
+}
+
+func (f *Fake) addVSwitchServers(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	v := f.lookupVSwitch(w, r)
+	if v == nil {
+		return
+	}
+	if v.inProcess() {
+		writeError(w, http.StatusConflict, "VSWITCH_IN_PROCESS", "There is an update running for this vSwitch")
+		return
+	}
+	numbers, ok := f.formServers(w, r)
+	if !ok {
+		return
+	}
+	for _, n := range numbers {
+		attached := false
+		for _, s := range v.Servers {
+			if s.Number == n {
+				attached = true
+			}
+		}
+		if !attached {
+			v.Servers = append(v.Servers, &VSwitchServer{Number: n, Status: "processing", pending: f.opts.PendingPolls})
+		}
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (f *Fake) removeVSwitchServers(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	v := f.lookupVSwitch(w, r)
+	if v == nil {
+		return
+	}
+	if v.inProcess() {
+		writeError(w, http.StatusConflict, "VSWITCH_IN_PROCESS", "There is an update running for this vSwitch")
+		return
+	}
+	numbers, ok := f.formServers(w, r)
+	if !ok {
+		return
+	}
+	remove := make(map[int]bool, len(numbers))
+	for _, n := range numbers {
+		remove[n] = true
+	}
+	kept := v.Servers[:0]
+	for _, s := range v.Servers {
+		if !remove[s.Number] {
+			kept = append(kept, s)
+		}
+	}
+	v.Servers = kept
+	w.WriteHeader(http.StatusOK)
+}
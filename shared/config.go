@@ -1,7 +1,53 @@
 package shared
 
+import "time"
+
 type ProviderConfig struct {
 	Username string
 	Password string
 	BaseURL  string
+
+	// ReadOnly makes the client reject every request that could mutate
+	// anything, before it is sent.
+	ReadOnly bool
+	// AuditLogFile, if set, receives one JSON line per mutating request.
+	AuditLogFile string
+
+	MaxRetries   int
+	RetryWaitMin time.Duration
+	RetryWaitMax time.Duration
+
+	// RateLimits overrides the request budget of individual endpoint
+	// families, keyed by the first path segment ("server", "reset", ...).
+	RateLimits map[string]RateLimit
+
+	// RequestTimeout bounds a single Robot API request, including reading
+	// the response body. Zero means no timeout.
+	RequestTimeout  time.Duration
+	MaxIdleConns    int
+	IdleConnTimeout time.Duration
+	// ProxyURL overrides the proxy taken from HTTPS_PROXY and friends.
+	ProxyURL string
+	// CACertFile is a PEM bundle trusted in addition to the system roots.
+	CACertFile     string
+	ClientCertFile string
+	ClientKeyFile  string
+	// UserAgent is sent with every Robot API request.
+	UserAgent string
+
+	// RescueSSHPort is the port of the SSH server of the rescue system.
+	RescueSSHPort int
+	// PowerCycleWait is the pause between the two presses of the power
+	// button during a power reset.
+	PowerCycleWait time.Duration
+
+	// PollMinInterval and PollMaxInterval bound the pause between two
+	// checks while waiting for an asynchronous operation.
+	PollMinInterval time.Duration
+	PollMaxInterval time.Duration
+}
+
+type RateLimit struct {
+	RequestsPerHour int
+	Burst           int
 }